@@ -2,11 +2,14 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/online-library/internal/config"
+	"github.com/yourusername/online-library/internal/contentfilter"
 	"github.com/yourusername/online-library/internal/database"
+	"github.com/yourusername/online-library/internal/geocoding"
 	"github.com/yourusername/online-library/internal/handlers"
 	"github.com/yourusername/online-library/internal/middleware"
 	"github.com/yourusername/online-library/internal/repository"
@@ -27,28 +30,80 @@ func main() {
 	}
 	defer db.Close()
 
+	// Initialize geocoding: online provider first when configured, offline gazetteer as fallback
+	gazetteer, err := geocoding.NewGazetteer()
+	if err != nil {
+		log.Fatal("Failed to load gazetteer:", err)
+	}
+	geocoder := geocoding.Chain{}
+	if cfg.Geocoder.URL != "" {
+		geocoder = append(geocoder, geocoding.NewHTTPGeocoder(cfg.Geocoder.URL, cfg.Geocoder.UserAgent))
+	}
+	geocoder = append(geocoder, gazetteer)
+
+	// Initialize content filter: built-in Bengali and English wordlists plus any configured extras
+	wordlist, err := contentfilter.NewWordlist(cfg.Filter.Wordlists...)
+	if err != nil {
+		log.Fatal("Failed to load wordlists:", err)
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.DB)
 	ideaRepo := repository.NewIdeaRepository(db.DB)
+	commentRepo := repository.NewCommentRepository(db.DB)
 	donationRepo := repository.NewDonationRepository(db.DB)
 	reviewRepo := repository.NewReviewRepository(db.DB)
+	reviewDisputeRepo := repository.NewReviewDisputeRepository(db.DB)
 	bookmarkRepo := repository.NewBookmarkRepository(db.DB)
 	bookRepo := repository.NewBookRepository(db.DB)
+	matchingPolicyRepo := repository.NewMatchingPolicyRepository(db.DB)
+	scoreRuleRepo := repository.NewScoreRuleRepository(db.DB)
+	requestRepo := repository.NewRequestRepository(db.DB)
+	interestRepo := repository.NewInterestRepository(db.DB)
+	taxonomyRepo := repository.NewTaxonomyRepository(db.DB)
+	reportRepo := repository.NewReportRepository(db.DB)
+	submissionRepo := repository.NewSubmissionRepository(db.DB)
+	badgeRepo := repository.NewBadgeRepository(db.DB)
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg.JWT.Secret)
-	successScoreService := services.NewSuccessScoreService(db.DB)
+	authService := services.NewAuthService(userRepo, scoreRuleRepo, cfg.JWT.Secret)
+	lendingPolicyService := services.NewLendingPolicyService(db.DB, scoreRuleRepo, requestRepo)
+	successScoreService := services.NewSuccessScoreService(db.DB, scoreRuleRepo)
 	notificationService := services.NewNotificationService(db.DB)
-	// matchingService := services.NewMatchingService(db.DB) // Available for future use
+	auditService := services.NewAuditService(db.DB)
+	locationService := services.NewLocationService(geocoder, gazetteer)
+	matchingService := services.NewMatchingService(db.DB, matchingPolicyRepo, lendingPolicyService)
+	recommendationService := services.NewRecommendationService(db.DB, bookRepo)
+	interestService := services.NewInterestService(db.DB, interestRepo, matchingService)
+	similarityService := services.NewSimilarityService(db.DB, bookRepo)
+	taxonomyService := services.NewTaxonomyService(taxonomyRepo)
+	trustService := services.NewTrustService(reviewRepo)
+	contentFilterService := services.NewContentFilterService(wordlist, submissionRepo, reportRepo)
+	achievementService := services.NewAchievementService(badgeRepo, notificationService)
+
+	// Background jobs
+	interestService.Start(24 * time.Hour)
+	similarityService.Start(6 * time.Hour)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(db.DB)
-	ideaHandler := handlers.NewIdeaHandler(ideaRepo, successScoreService, notificationService)
-	donationHandler := handlers.NewDonationHandler(donationRepo, successScoreService, db.DB)
-	reviewHandler := handlers.NewReviewHandler(reviewRepo, successScoreService, notificationService)
-	bookmarkHandler := handlers.NewBookmarkHandler(bookmarkRepo)
-	bookHandler := handlers.NewBookHandler(bookRepo)
+	authHandler := handlers.NewAuthHandler(authService, lendingPolicyService)
+	userHandler := handlers.NewUserHandler(db.DB, locationService, matchingService, taxonomyService, trustService, contentFilterService, lendingPolicyService, achievementService)
+	ideaHandler := handlers.NewIdeaHandler(ideaRepo, successScoreService, notificationService, auditService, taxonomyService, contentFilterService, achievementService)
+	commentHandler := handlers.NewCommentHandler(commentRepo, ideaRepo, userRepo, notificationService)
+	donationHandler := handlers.NewDonationHandler(donationRepo, successScoreService, achievementService, db.DB)
+	reviewHandler := handlers.NewReviewHandler(reviewRepo, successScoreService, notificationService, contentFilterService)
+	reviewDisputeHandler := handlers.NewReviewDisputeHandler(reviewDisputeRepo, reviewRepo, successScoreService, notificationService, auditService)
+	bookmarkHandler := handlers.NewBookmarkHandler(bookmarkRepo, interestService)
+	bookHandler := handlers.NewBookHandler(bookRepo, userRepo, taxonomyService, locationService)
+	locationHandler := handlers.NewLocationHandler(locationService)
+	matchingHandler := handlers.NewMatchingHandler(matchingPolicyRepo, requestRepo, bookRepo, userRepo, matchingService, trustService)
+	lendingHandler := handlers.NewLendingHandler(requestRepo, bookRepo, lendingPolicyService, matchingService, successScoreService, notificationService, achievementService)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
+	interestHandler := handlers.NewInterestHandler(interestRepo, matchingService, taxonomyService)
+	taxonomyHandler := handlers.NewTaxonomyHandler(taxonomyRepo)
+	scoreHandler := handlers.NewScoreHandler(scoreRuleRepo, successScoreService)
+	badgeHandler := handlers.NewBadgeHandler(badgeRepo, achievementService)
+	reportHandler := handlers.NewReportHandler(reportRepo, ideaRepo, commentRepo, reviewRepo, successScoreService, notificationService, auditService)
 
 	// Setup router
 	router := gin.Default()
@@ -79,28 +134,63 @@ func main() {
 	api.Use(middleware.AuthMiddleware(authService))
 	{
 		api.GET("/me", authHandler.Me)
+		api.GET("/me/standing", lendingHandler.GetStanding)
 
 		// User routes
 		api.GET("/users/:id/profile", userHandler.GetPublicProfile)
 		api.GET("/users/:id/reviews", reviewHandler.GetByUser)
 		api.PUT("/users/profile", userHandler.UpdateProfile)
 		api.POST("/users/interests", userHandler.AddInterests)
+		api.GET("/users/interests", interestHandler.GetMine)
+		api.PUT("/users/interests/:interest", interestHandler.SetWeight)
+		api.DELETE("/users/interests/:interest", interestHandler.Delete)
 		api.GET("/leaderboard", userHandler.GetLeaderboard)
+		api.GET("/badges", badgeHandler.GetAll)
+
+		// Location routes
+		api.GET("/locations/districts", locationHandler.GetDistricts)
+		api.GET("/locations/geocode", locationHandler.Geocode)
 
 		// Book routes
 		api.GET("/books", bookHandler.GetAll)
 		api.POST("/books", bookHandler.Create)
+		api.GET("/books/nearby", bookHandler.GetNearby)
 		api.GET("/books/:id", bookHandler.GetByID)
 		api.GET("/books/:id/ideas", ideaHandler.GetByBook)
+		api.GET("/books/:id/highlights", ideaHandler.GetHighlights)
+		api.GET("/books/:id/similar", bookHandler.GetSimilar)
+		api.GET("/books/:id/requests", matchingHandler.GetBookRequests)
 		api.PATCH("/books/:id", bookHandler.Update)
 		api.DELETE("/books/:id", bookHandler.Delete)
+		api.GET("/recommendations", recommendationHandler.GetForUser)
+
+		// Taxonomy routes
+		api.GET("/taxonomy/terms", taxonomyHandler.GetTerms)
 
 		// Reading ideas routes
+		api.GET("/ideas", ideaHandler.GetFeed)
+		api.GET("/ideas/feed", ideaHandler.GetMyFeed)
 		api.POST("/ideas", ideaHandler.Create)
+		api.PATCH("/ideas/:id", ideaHandler.Update)
+		api.DELETE("/ideas/:id", ideaHandler.Delete)
+		api.GET("/ideas/:id/revisions", ideaHandler.GetRevisions)
 		api.POST("/ideas/:id/vote", ideaHandler.Vote)
+		api.DELETE("/ideas/:id/vote", ideaHandler.Unvote)
+		api.GET("/ideas/:id/comments", commentHandler.GetByIdea)
+		api.POST("/ideas/:id/comments", commentHandler.Create)
+
+		// Comment routes
+		api.PATCH("/comments/:id", commentHandler.Update)
+		api.DELETE("/comments/:id", commentHandler.Delete)
+		api.POST("/comments/:id/vote", commentHandler.Vote)
+		api.DELETE("/comments/:id/vote", commentHandler.Unvote)
 
 		// Review routes
 		api.POST("/reviews", reviewHandler.Create)
+		api.GET("/reviews/pending", reviewHandler.GetPending)
+		api.PATCH("/reviews/:id", reviewHandler.Update)
+		api.POST("/reviews/:id/disputes", reviewDisputeHandler.Create)
+		api.GET("/reviews/disputes/:id", reviewDisputeHandler.GetByID)
 
 		// Donation routes
 		api.POST("/donations", donationHandler.Create)
@@ -110,6 +200,52 @@ func main() {
 		api.POST("/bookmarks", bookmarkHandler.Create)
 		api.DELETE("/bookmarks/:bookId", bookmarkHandler.Delete)
 		api.GET("/bookmarks", bookmarkHandler.GetByUser)
+
+		// Content report routes
+		api.POST("/reports", reportHandler.Create)
+
+		// Book request routes
+		api.POST("/books/:id/request", lendingHandler.Request)
+		api.POST("/books/:id/return", lendingHandler.Return)
+		api.POST("/books/:id/renew", lendingHandler.Renew)
+		api.POST("/requests/process", lendingHandler.Process)
+		api.GET("/requests/:id/score", matchingHandler.GetRequestScore)
+		api.GET("/matching/policy", matchingHandler.GetActivePolicy)
+		api.GET("/score-rules", scoreHandler.GetActiveRules)
+	}
+
+	// Moderation routes
+	moderation := api.Group("/moderation")
+	moderation.Use(middleware.ModeratorOnly())
+	{
+		moderation.GET("/reports", reportHandler.GetQueue)
+		moderation.GET("/reports/:type/:id", reportHandler.GetReports)
+		moderation.POST("/reports/:type/:id/resolve", reportHandler.Resolve)
+	}
+
+	// Admin routes
+	admin := api.Group("/admin")
+	admin.Use(middleware.AdminOnly())
+	{
+		admin.GET("/matching-policies", matchingHandler.GetPolicies)
+		admin.POST("/matching-policies", matchingHandler.CreatePolicy)
+		admin.POST("/matching-policies/:version/activate", matchingHandler.ActivatePolicy)
+		admin.POST("/matching/rescore", matchingHandler.Rescore)
+		admin.GET("/score-rules", scoreHandler.GetRuleSets)
+		admin.POST("/score-rules", scoreHandler.CreateRuleSet)
+		admin.POST("/score-rules/:version/activate", scoreHandler.ActivateRuleSet)
+		admin.POST("/scores/recompute", scoreHandler.Recompute)
+		admin.POST("/badges", badgeHandler.Create)
+		admin.PATCH("/badges/:id", badgeHandler.Update)
+		admin.POST("/badges/backfill", badgeHandler.Backfill)
+		admin.GET("/review-disputes", reviewDisputeHandler.GetQueue)
+		admin.POST("/review-disputes/:id/resolve", reviewDisputeHandler.Resolve)
+		admin.POST("/taxonomy/terms", taxonomyHandler.CreateTerm)
+		admin.PATCH("/taxonomy/terms/:id", taxonomyHandler.UpdateTerm)
+		admin.DELETE("/taxonomy/terms/:id", taxonomyHandler.DeleteTerm)
+		admin.POST("/taxonomy/terms/:id/aliases", taxonomyHandler.AddAlias)
+		admin.DELETE("/taxonomy/terms/:id/aliases/:alias", taxonomyHandler.DeleteAlias)
+		admin.POST("/taxonomy/terms/:id/merge", taxonomyHandler.MergeTerm)
 	}
 
 	// Start server
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yourusername/online-library/internal/config"
+	"github.com/yourusername/online-library/internal/database"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+// backfill-badges awards the badges members earned before the badge existed
+// or before achievements were tracked. Badges already held are skipped, so
+// it is safe to run again.
+//
+//	go run cmd/backfill-badges/main.go
+func main() {
+	userID := flag.String("user", "", "only backfill this member")
+	asJSON := flag.Bool("json", false, "print awards as JSON")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	db, err := database.Connect(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	achievements := services.NewAchievementService(repository.NewBadgeRepository(db.DB), services.NewNotificationService(db.DB))
+	awards, err := achievements.Backfill(*userID)
+	if err != nil {
+		log.Fatal("Failed to backfill badges:", err)
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"awarded": awards,
+		})
+		return
+	}
+
+	fmt.Printf("Awarded %d badges\n\n", len(awards))
+	if len(awards) == 0 {
+		return
+	}
+	fmt.Printf("%-38s %-24s %s\n", "member", "badge", "metric")
+	for _, a := range awards {
+		fmt.Printf("%-38s %-24s %s\n", a.UserID, a.Badge.Name, a.Badge.Metric)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yourusername/online-library/internal/config"
+	"github.com/yourusername/online-library/internal/database"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+// recompute rebuilds users.success_score from the score history under the
+// active score rules (or a stored version), printing what changes.
+//
+//	go run cmd/recompute/main.go -dry-run
+func main() {
+	rulesVersion := flag.Int("rules-version", 0, "stored rule set version to apply (0 = active rules)")
+	userID := flag.String("user", "", "only recompute this member")
+	dryRun := flag.Bool("dry-run", false, "show the differences without writing them")
+	asJSON := flag.Bool("json", false, "print results as JSON")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	db, err := database.Connect(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	ruleRepo := repository.NewScoreRuleRepository(db.DB)
+	rules, err := loadRules(ruleRepo, *rulesVersion)
+	if err != nil {
+		log.Fatal("Failed to load score rules:", err)
+	}
+
+	changes, err := services.NewSuccessScoreService(db.DB, ruleRepo).Recompute(rules, *userID, !*dryRun)
+	if err != nil {
+		log.Fatal("Failed to recompute scores:", err)
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"rules_version": rules.Version,
+			"dry_run":       *dryRun,
+			"changes":       changes,
+		})
+		return
+	}
+
+	verb := "Updated"
+	if *dryRun {
+		verb = "Would update"
+	}
+	fmt.Printf("Rules: version %d (%s)\n", rules.Version, rules.Notes)
+	fmt.Printf("%s %d members\n\n", verb, len(changes))
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Printf("%-24s %10s %10s %8s %8s\n", "member", "current", "rebuilt", "diff", "events")
+	for _, c := range changes {
+		fmt.Printf("%-24s %10d %10d %+8d %8d\n", c.Username, c.CurrentScore, c.RebuiltScore, c.Difference, c.ChangedEntries)
+	}
+}
+
+func loadRules(repo *repository.ScoreRuleRepository, version int) (*models.ScoreRuleSet, error) {
+	if version > 0 {
+		return repo.GetByVersion(version)
+	}
+	return repo.GetActive()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/yourusername/online-library/internal/config"
+	"github.com/yourusername/online-library/internal/database"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/simulation"
+)
+
+// simulate replays historical book hand-overs under a matching policy and
+// compares fairness metrics with what actually happened.
+//
+//	go run cmd/simulate/main.go -policy-file candidate.json -since 2025-01-01
+func main() {
+	policyVersion := flag.Int("policy-version", 0, "stored policy version to replay (0 = active policy)")
+	policyFile := flag.String("policy-file", "", "JSON file with a candidate policy (overrides -policy-version)")
+	sinceFlag := flag.String("since", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"), "start of replay window (YYYY-MM-DD)")
+	untilFlag := flag.String("until", time.Now().AddDate(0, 0, 1).Format("2006-01-02"), "end of replay window (YYYY-MM-DD)")
+	lowScore := flag.Int("low-score", 80, "success score below which a member counts as low-score")
+	asJSON := flag.Bool("json", false, "print results as JSON")
+	flag.Parse()
+
+	since, err := time.Parse("2006-01-02", *sinceFlag)
+	if err != nil {
+		log.Fatal("Invalid -since:", err)
+	}
+	until, err := time.Parse("2006-01-02", *untilFlag)
+	if err != nil {
+		log.Fatal("Invalid -until:", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	db, err := database.Connect(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	policy, err := loadPolicy(repository.NewMatchingPolicyRepository(db.DB), *policyVersion, *policyFile)
+	if err != nil {
+		log.Fatal("Failed to load policy:", err)
+	}
+
+	rules, err := repository.NewScoreRuleRepository(db.DB).GetActive()
+	if err != nil {
+		log.Fatal("Failed to load score rules:", err)
+	}
+
+	history, err := simulation.Load(db.DB, since, until)
+	if err != nil {
+		log.Fatal("Failed to load history:", err)
+	}
+
+	requesters := history.Requesters()
+	actual := simulation.Measure(simulation.Actual(history), requesters, *lowScore)
+	simulated := simulation.Measure(simulation.Replay(history, policy, rules), requesters, *lowScore)
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"policy":    policy,
+			"since":     since.Format("2006-01-02"),
+			"until":     until.Format("2006-01-02"),
+			"actual":    actual,
+			"simulated": simulated,
+		})
+		return
+	}
+
+	fmt.Printf("Replaying %d hand-overs and %d requests from %s to %s\n",
+		len(history.Allocations), len(history.Requests), since.Format("2006-01-02"), until.Format("2006-01-02"))
+	fmt.Printf("Policy: version %d (%s)\n\n", policy.Version, policy.Notes)
+	fmt.Printf("%-28s %12s %12s\n", "", "actual", "simulated")
+	fmt.Printf("%-28s %12d %12d\n", "allocations", actual.Allocations, simulated.Allocations)
+	fmt.Printf("%-28s %12d %12d\n", "contested", actual.Contested, simulated.Contested)
+	fmt.Printf("%-28s %12d %12d\n", "distinct winners", actual.DistinctWinners, simulated.DistinctWinners)
+	fmt.Printf("%-28s %12.3f %12.3f\n", "gini of allocations", actual.Gini, simulated.Gini)
+	fmt.Printf("%-28s %12.1f %12.1f\n", "median wait (days)", actual.MedianWaitDays, simulated.MedianWaitDays)
+	fmt.Printf("%-28s %12.1f %12.1f\n", "avg distance (km)", actual.AvgDistanceKm, simulated.AvgDistanceKm)
+	fmt.Printf("%-28s %11.1f%% %11.1f%%\n", fmt.Sprintf("share to score < %d", *lowScore),
+		actual.LowScoreShare*100, simulated.LowScoreShare*100)
+}
+
+func loadPolicy(repo *repository.MatchingPolicyRepository, version int, file string) (*models.MatchingPolicy, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		policy := &models.MatchingPolicy{
+			DistanceDecay:     models.DecayLinear,
+			DistanceScaleKm:   1000,
+			MissingDistanceKm: 10000,
+			WinPeriodDays:     30,
+			TieBreak:          models.TieBreakOldestRequest,
+			Notes:             file,
+		}
+		if err := json.Unmarshal(data, policy); err != nil {
+			return nil, err
+		}
+		return policy, nil
+	}
+	if version > 0 {
+		return repo.GetByVersion(version)
+	}
+	return repo.GetActive()
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +12,8 @@ type Config struct {
 	Database DatabaseConfig
 	Server   ServerConfig
 	JWT      JWTConfig
+	Geocoder GeocoderConfig
+	Filter   FilterConfig
 }
 
 type DatabaseConfig struct {
@@ -31,6 +34,15 @@ type JWTConfig struct {
 	RefreshTokenTTL int // hours
 }
 
+type GeocoderConfig struct {
+	URL       string // Nominatim-compatible endpoint; empty disables online lookups
+	UserAgent string
+}
+
+type FilterConfig struct {
+	Wordlists []string // extra CSV wordlists loaded after the built-in ones
+}
+
 func Load() (*Config, error) {
 	godotenv.Load()
 
@@ -50,6 +62,13 @@ func Load() (*Config, error) {
 			AccessTokenTTL:  24,
 			RefreshTokenTTL: 168, // 7 days
 		},
+		Geocoder: GeocoderConfig{
+			URL:       getEnv("GEOCODER_URL", ""),
+			UserAgent: getEnv("GEOCODER_USER_AGENT", "amar-pathagar/1.0"),
+		},
+		Filter: FilterConfig{
+			Wordlists: getEnvList("FILTER_WORDLISTS"),
+		},
 	}
 
 	return config, nil
@@ -62,6 +81,17 @@ func (c *DatabaseConfig) ConnectionString() string {
 	)
 }
 
+// getEnvList reads a comma-separated list, skipping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,89 @@
+// Package contentfilter screens user-written text before it is posted. A
+// Pipeline runs a list of Filters and combines their verdicts.
+package contentfilter
+
+import "time"
+
+type Verdict string
+
+const (
+	Allow  Verdict = "allow"
+	Flag   Verdict = "flag"
+	Reject Verdict = "reject"
+)
+
+func (v Verdict) severity() int {
+	switch v {
+	case Reject:
+		return 2
+	case Flag:
+		return 1
+	}
+	return 0
+}
+
+// Reasons given by the built-in filters
+const (
+	ReasonProfanity = "profanity"
+	ReasonLinks     = "links"
+	ReasonDuplicate = "duplicate"
+	ReasonRateLimit = "rate_limit"
+)
+
+// Post is a piece of text about to be saved. ResourceID is set when an
+// existing resource is being edited.
+type Post struct {
+	UserID     string
+	Kind       string
+	ResourceID string
+	Text       string
+}
+
+type Result struct {
+	Verdict Verdict  `json:"verdict"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+func allow() Result {
+	return Result{Verdict: Allow}
+}
+
+// Filter judges a post on one criterion.
+type Filter interface {
+	Check(post Post) (Result, error)
+}
+
+// Pipeline runs filters in order. The result is the most severe verdict
+// with the reasons of every filter that returned it; a rejection stops the
+// pipeline early.
+type Pipeline []Filter
+
+func (p Pipeline) Check(post Post) (Result, error) {
+	result := allow()
+	for _, f := range p {
+		r, err := f.Check(post)
+		if err != nil {
+			return Result{}, err
+		}
+		switch {
+		case r.Verdict.severity() > result.Verdict.severity():
+			result = r
+		case r.Verdict == result.Verdict && r.Verdict != Allow:
+			result.Reasons = append(result.Reasons, r.Reasons...)
+		}
+		if result.Verdict == Reject {
+			break
+		}
+	}
+	return result, nil
+}
+
+// History is what the duplicate and rate limit filters need to know about
+// a member's earlier posts.
+type History interface {
+	// CountPosts counts the user's posts of a kind since the given time.
+	CountPosts(userID, kind string, since time.Time) (int, error)
+	// CountDuplicates counts earlier posts with the same fingerprint since
+	// the given time, by this user and by anyone, leaving out excludeID.
+	CountDuplicates(userID, fingerprint, excludeID string, since time.Time) (own, all int, err error)
+}
@@ -0,0 +1,123 @@
+package contentfilter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Digits and symbols commonly swapped in for letters to dodge filters
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i',
+}
+
+// Invisible characters that can be slipped inside a word. ZWJ and ZWNJ
+// also shape Bengali conjuncts, but the word reads the same without them.
+var invisible = map[rune]bool{
+	'\u00ad': true, '\u200b': true, '\u200c': true, '\u200d': true, '\u2060': true, '\ufeff': true,
+}
+
+// Tokens splits text into normalized words: Unicode-normalized, lowercase,
+// with invisible characters and in-word punctuation dropped and lookalike
+// digits and symbols read as letters ("f.u.c.k", "sh1t"). Runs of
+// single-letter words are also joined into one ("f u c k").
+func Tokens(text string) []string {
+	text = strings.ToLower(norm.NFKC.String(text))
+
+	var tokens []string
+	var spelled strings.Builder
+	flushSpelled := func() {
+		if len([]rune(spelled.String())) > 1 {
+			tokens = append(tokens, spelled.String())
+		}
+		spelled.Reset()
+	}
+
+	for _, field := range strings.Fields(text) {
+		word := cleanWord(field)
+		if word == "" {
+			continue
+		}
+		tokens = append(tokens, word)
+		if len([]rune(word)) == 1 {
+			spelled.WriteString(word)
+		} else {
+			flushSpelled()
+		}
+	}
+	flushSpelled()
+	return tokens
+}
+
+func cleanWord(field string) string {
+	// Only symbols inside a word stand in for letters; "wow!" is not "wowi"
+	field = strings.TrimFunc(field, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r)
+	})
+
+	hasLetter := false
+	for _, r := range field {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+			break
+		}
+	}
+	if !hasLetter {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, r := range field {
+		if invisible[r] {
+			continue
+		}
+		if l, ok := leet[r]; ok {
+			r = l
+		}
+		if unicode.IsLetter(r) || unicode.IsMark(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// squeeze shortens runs of three or more of the same letter to n letters,
+// so "fuuuuck" can be matched as "fuck" and "asssss" as "ass".
+func squeeze(word string, n int) string {
+	runes := []rune(word)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		count := j - i
+		if count >= 3 {
+			count = n
+		}
+		for k := 0; k < count; k++ {
+			b.WriteRune(runes[i])
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// Fingerprint identifies a text regardless of case, spacing, punctuation
+// and the obfuscations Tokens undoes. Texts too short to be meaningful as
+// spam have no fingerprint.
+func Fingerprint(text string) string {
+	joined := strings.Join(Tokens(text), " ")
+	if len([]rune(joined)) < minFingerprintLength {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(joined))
+	return hex.EncodeToString(sum[:])
+}
+
+// Short posts like "Great book!" repeat innocently
+const minFingerprintLength = 30
@@ -0,0 +1,85 @@
+package contentfilter
+
+import (
+	"regexp"
+	"time"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>()]+|\b[a-z0-9][a-z0-9-]*\.(?:com|net|org|info|biz|xyz|top|click|site|online|shop|ru|cn|io|co|me|bd)\b(?:/[^\s<>()]*)?`)
+
+// LinkFilter catches posts that are mostly there to spread links.
+type LinkFilter struct {
+	FlagAt   int // this many links flags a post
+	RejectAt int // this many rejects it
+}
+
+func (f LinkFilter) Check(post Post) (Result, error) {
+	n := len(linkPattern.FindAllString(post.Text, -1))
+	switch {
+	case f.RejectAt > 0 && n >= f.RejectAt:
+		return Result{Verdict: Reject, Reasons: []string{ReasonLinks}}, nil
+	case f.FlagAt > 0 && n >= f.FlagAt:
+		return Result{Verdict: Flag, Reasons: []string{ReasonLinks}}, nil
+	}
+	return allow(), nil
+}
+
+// DuplicateFilter catches the same text posted again within Window, either
+// by the same member (copy-paste spam across books) or by several members
+// (coordinated spam). Edits of the post being checked don't count.
+type DuplicateFilter struct {
+	History   History
+	Window    time.Duration
+	FlagOwn   int // earlier copies by the same member that flag a post
+	RejectOwn int // ...and that reject it
+	FlagAny   int // earlier copies by anyone that flag a post
+	RejectAny int // ...and that reject it
+}
+
+func (f DuplicateFilter) Check(post Post) (Result, error) {
+	fingerprint := Fingerprint(post.Text)
+	if fingerprint == "" {
+		return allow(), nil
+	}
+
+	own, all, err := f.History.CountDuplicates(post.UserID, fingerprint, post.ResourceID, time.Now().Add(-f.Window))
+	if err != nil {
+		return Result{}, err
+	}
+	switch {
+	case own >= f.RejectOwn || all >= f.RejectAny:
+		return Result{Verdict: Reject, Reasons: []string{ReasonDuplicate}}, nil
+	case own >= f.FlagOwn || all >= f.FlagAny:
+		return Result{Verdict: Flag, Reasons: []string{ReasonDuplicate}}, nil
+	}
+	return allow(), nil
+}
+
+// Limit is how many posts of one kind a member may make per window.
+type Limit struct {
+	Posts  int
+	Window time.Duration
+}
+
+// RateLimitFilter rejects posts beyond a member's per-kind limit. Kinds
+// without a limit are not limited.
+type RateLimitFilter struct {
+	History History
+	Limits  map[string]Limit
+}
+
+func (f RateLimitFilter) Check(post Post) (Result, error) {
+	limit, ok := f.Limits[post.Kind]
+	if !ok {
+		return allow(), nil
+	}
+
+	n, err := f.History.CountPosts(post.UserID, post.Kind, time.Now().Add(-limit.Window))
+	if err != nil {
+		return Result{}, err
+	}
+	if n >= limit.Posts {
+		return Result{Verdict: Reject, Reasons: []string{ReasonRateLimit}}, nil
+	}
+	return allow(), nil
+}
@@ -0,0 +1,135 @@
+package contentfilter
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//go:embed data/wordlist_*.csv
+var defaultWordlists embed.FS
+
+type prefixTerm struct {
+	prefix  string
+	verdict Verdict
+}
+
+// Wordlist flags or rejects posts containing listed words. Lists are CSV
+// files of "verdict,term" rows, where the verdict is flag or reject. A
+// term ending in "*" matches any word starting with it, and a term of
+// several words matches them in sequence. Terms are normalized the same
+// way as posts, so one entry covers its obfuscated spellings.
+type Wordlist struct {
+	words    map[string]Verdict
+	prefixes []prefixTerm
+	phrases  map[string]Verdict
+}
+
+// NewWordlist loads the built-in English and Bengali lists followed by any
+// extra files, which add terms or override the verdict of existing ones.
+func NewWordlist(extraFiles ...string) (*Wordlist, error) {
+	w := &Wordlist{words: make(map[string]Verdict), phrases: make(map[string]Verdict)}
+
+	entries, err := defaultWordlists.ReadDir("data")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		f, err := defaultWordlists.Open("data/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		err = w.load(e.Name(), f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range extraFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open wordlist: %w", err)
+		}
+		err = w.load(path, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *Wordlist) load(name string, r io.Reader) error {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read wordlist %s: %w", name, err)
+	}
+
+	for i, rec := range records {
+		if i == 0 && rec[0] == "verdict" {
+			continue
+		}
+		if len(rec) != 2 {
+			return fmt.Errorf("wordlist %s line %d: expected 2 fields", name, i+1)
+		}
+		verdict := Verdict(strings.TrimSpace(rec[0]))
+		if verdict != Flag && verdict != Reject {
+			return fmt.Errorf("wordlist %s line %d: unknown verdict %q", name, i+1, rec[0])
+		}
+
+		term := strings.TrimSpace(rec[1])
+		prefix := strings.HasSuffix(term, "*")
+		tokens := Tokens(strings.TrimSuffix(term, "*"))
+		switch {
+		case len(tokens) == 0:
+			return fmt.Errorf("wordlist %s line %d: empty term", name, i+1)
+		case len(tokens) > 1:
+			w.phrases[strings.Join(tokens, " ")] = verdict
+		case prefix:
+			w.prefixes = append(w.prefixes, prefixTerm{tokens[0], verdict})
+		default:
+			w.words[tokens[0]] = verdict
+		}
+	}
+	return nil
+}
+
+func (w *Wordlist) Check(post Post) (Result, error) {
+	tokens := Tokens(post.Text)
+	worst := Allow
+
+	match := func(v Verdict) {
+		if v.severity() > worst.severity() {
+			worst = v
+		}
+	}
+	for _, token := range tokens {
+		for _, form := range []string{token, squeeze(token, 1), squeeze(token, 2)} {
+			if v, ok := w.words[form]; ok {
+				match(v)
+			}
+			for _, p := range w.prefixes {
+				if strings.HasPrefix(form, p.prefix) {
+					match(p.verdict)
+				}
+			}
+		}
+	}
+	if len(w.phrases) > 0 {
+		joined := " " + strings.Join(tokens, " ") + " "
+		for phrase, v := range w.phrases {
+			if strings.Contains(joined, " "+phrase+" ") {
+				match(v)
+			}
+		}
+	}
+
+	if worst == Allow {
+		return allow(), nil
+	}
+	return Result{Verdict: worst, Reasons: []string{ReasonProfanity}}, nil
+}
@@ -29,4 +29,9 @@ type UserDTO struct {
 	SuccessScore  int    `json:"success_score"`
 	BooksShared   int    `json:"books_shared"`
 	BooksReceived int    `json:"books_received"`
+
+	// Only filled in by /me
+	Tier        *TierSummary `json:"tier,omitempty"`
+	ActiveLoans int          `json:"active_loans,omitempty"`
+	CanRequest  *bool        `json:"can_request,omitempty"`
 }
@@ -0,0 +1,20 @@
+package dto
+
+type CreateBadgeRequest struct {
+	Code        string `json:"code" binding:"required,max=50"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"required"`
+	Icon        string `json:"icon" binding:"max=50"`
+	Metric      string `json:"metric" binding:"required"`
+	Threshold   int    `json:"threshold" binding:"required,min=1"`
+}
+
+// UpdateBadgeRequest changes a badge. Its code and metric are fixed, since
+// members may already hold it.
+type UpdateBadgeRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description"`
+	Icon        *string `json:"icon" binding:"omitempty,max=50"`
+	Threshold   *int    `json:"threshold" binding:"omitempty,min=1"`
+	IsActive    *bool   `json:"is_active"`
+}
@@ -28,3 +28,10 @@ type UpdateBookRequest struct {
 type BookRequestRequest struct {
 	BookID string `json:"book_id" binding:"required"`
 }
+
+type NearbyBooksQuery struct {
+	Lat      *float64 `form:"lat" binding:"omitempty,min=-90,max=90"`
+	Lng      *float64 `form:"lng" binding:"omitempty,min=-180,max=180"`
+	RadiusKm float64  `form:"radius_km" binding:"omitempty,gt=0,max=500"`
+	Limit    int      `form:"limit" binding:"omitempty,min=1,max=100"`
+}
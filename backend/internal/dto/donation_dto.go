@@ -5,7 +5,7 @@ type CreateDonationRequest struct {
 	BookID       *string  `json:"book_id"`
 	Amount       *float64 `json:"amount"`
 	Currency     string   `json:"currency"`
-	Message      string   `json:"message"`
+	Message      string   `json:"message" binding:"max=1000"`
 	IsPublic     bool     `json:"is_public"`
 }
 
@@ -19,5 +19,6 @@ type DonationResponse struct {
 	Amount       *float64 `json:"amount"`
 	Currency     string   `json:"currency"`
 	Message      string   `json:"message"`
+	MessageHTML  string   `json:"message_html"`
 	CreatedAt    string   `json:"created_at"`
 }
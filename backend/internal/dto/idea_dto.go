@@ -1,25 +1,101 @@
 package dto
 
+// CreateIdeaRequest posts an idea, optionally anchored to a chapter, a page
+// range and a quote from the book.
 type CreateIdeaRequest struct {
-	BookID  string `json:"book_id" binding:"required"`
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	BookID    string `json:"book_id" binding:"required"`
+	Title     string `json:"title" binding:"required,max=500"`
+	Content   string `json:"content" binding:"required,max=20000"`
+	Chapter   string `json:"chapter" binding:"max=200"`
+	PageStart *int   `json:"page_start" binding:"omitempty,min=1"`
+	PageEnd   *int   `json:"page_end" binding:"omitempty,min=1"`
+	Quote     string `json:"quote" binding:"max=2000"`
 }
 
+// UpdateIdeaRequest edits an idea; omitted fields are left unchanged. An
+// empty chapter or quote, or a page of 0, clears it.
+type UpdateIdeaRequest struct {
+	Title     *string `json:"title" binding:"omitempty,min=1,max=500"`
+	Content   *string `json:"content" binding:"omitempty,min=1,max=20000"`
+	Chapter   *string `json:"chapter" binding:"omitempty,max=200"`
+	PageStart *int    `json:"page_start" binding:"omitempty,min=0"`
+	PageEnd   *int    `json:"page_end" binding:"omitempty,min=0"`
+	Quote     *string `json:"quote" binding:"omitempty,max=2000"`
+}
+
+// IdeaListQuery filters a book's ideas by chapter and/or a page the idea's
+// range covers.
+type IdeaListQuery struct {
+	Chapter string `form:"chapter"`
+	Page    int    `form:"page" binding:"omitempty,min=1"`
+}
+
+type HighlightQuery struct {
+	Chapter string `form:"chapter"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// VoteIdeaRequest sets the caller's vote; "none" withdraws it.
 type VoteIdeaRequest struct {
-	VoteType string `json:"vote_type" binding:"required,oneof=upvote downvote"`
+	VoteType string `json:"vote_type" binding:"required,oneof=upvote downvote none"`
 }
 
 type IdeaResponse struct {
-	ID        string `json:"id"`
-	BookID    string `json:"book_id"`
-	BookTitle string `json:"book_title"`
-	UserID    string `json:"user_id"`
-	Username  string `json:"username"`
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	Upvotes   int    `json:"upvotes"`
-	Downvotes int    `json:"downvotes"`
-	NetScore  int    `json:"net_score"`
-	CreatedAt string `json:"created_at"`
+	ID           string `json:"id"`
+	BookID       string `json:"book_id"`
+	BookTitle    string `json:"book_title"`
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	Title        string `json:"title"`
+	Content      string `json:"content"`
+	ContentHTML  string `json:"content_html"`
+	Chapter      string `json:"chapter,omitempty"`
+	PageStart    *int   `json:"page_start,omitempty"`
+	PageEnd      *int   `json:"page_end,omitempty"`
+	Quote        string `json:"quote,omitempty"`
+	Upvotes      int    `json:"upvotes"`
+	Downvotes    int    `json:"downvotes"`
+	NetScore     int    `json:"net_score"`
+	CommentCount int    `json:"comment_count"`
+	Edited       bool   `json:"edited"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// IdeaFeedQuery filters the cross-book idea feed. Period only applies to
+// the "top" sort.
+type IdeaFeedQuery struct {
+	Sort     string `form:"sort" binding:"omitempty,oneof=new top hot"`
+	Period   string `form:"period" binding:"omitempty,oneof=day week month year all"`
+	BookID   string `form:"book_id"`
+	AuthorID string `form:"author_id"`
+	Topic    string `form:"topic"`
+	Cursor   string `form:"cursor"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type IdeaFeedResponse struct {
+	Ideas      []IdeaResponse `json:"ideas"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	Content  string `json:"content" binding:"required,max=5000"`
+	ParentID string `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
+
+type CommentListQuery struct {
+	Sort   string `form:"sort" binding:"omitempty,oneof=newest top"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type CommentListResponse struct {
+	Comments interface{} `json:"comments"`
+	Total    int         `json:"total"`
+	Limit    int         `json:"limit"`
+	Offset   int         `json:"offset"`
 }
@@ -0,0 +1,15 @@
+package dto
+
+// CreateReportRequest flags an idea, comment, review, a member's bio (by
+// user ID) or a donation message for moderators.
+type CreateReportRequest struct {
+	ResourceType string `json:"resource_type" binding:"required,oneof=idea comment review bio donation"`
+	ResourceID   string `json:"resource_id" binding:"required,uuid"`
+	Reason       string `json:"reason" binding:"required,oneof=spam harassment hate sexual misinformation other"`
+	Details      string `json:"details" binding:"max=1000"`
+}
+
+type ResolveReportsRequest struct {
+	Action string `json:"action" binding:"required,oneof=dismiss hide delete warn"`
+	Note   string `json:"note" binding:"max=2000"`
+}
@@ -24,3 +24,56 @@ type ProcessRequestRequest struct {
 	Action    string `json:"action" binding:"required,oneof=approve reject"`
 	DueDays   int    `json:"due_days"`
 }
+
+// RequestScoreResponse explains where a request ranks and why. Components
+// are already weighted and sum to PriorityScore; they are nil until the
+// request has been scored.
+type RequestScoreResponse struct {
+	RequestID          string   `json:"request_id"`
+	BookID             string   `json:"book_id"`
+	Status             string   `json:"status"`
+	Rank               int      `json:"rank,omitempty"`
+	Competing          int      `json:"competing,omitempty"`
+	PolicyVersion      *int     `json:"policy_version"`
+	PriorityScore      float64  `json:"priority_score"`
+	SuccessComponent   *float64 `json:"success_component"`
+	InterestComponent  *float64 `json:"interest_component"`
+	DistanceComponent  *float64 `json:"distance_component"`
+	WaitComponent      *float64 `json:"wait_component"`
+	TierComponent      *float64 `json:"tier_component"`
+	InterestMatchScore float64  `json:"interest_match_score"`
+	DistanceKm         *float64 `json:"distance_km"`
+	ScoredAt           *string  `json:"scored_at"`
+}
+
+// PendingRequestResponse is one requester in the list a holder sees when
+// deciding who gets the book next.
+type PendingRequestResponse struct {
+	RequestID     string        `json:"request_id"`
+	Rank          int           `json:"rank"`
+	UserID        string        `json:"user_id"`
+	Username      string        `json:"username"`
+	FullName      string        `json:"full_name"`
+	AvatarURL     string        `json:"avatar_url"`
+	SuccessScore  int           `json:"success_score"`
+	PriorityScore float64       `json:"priority_score"`
+	DistanceKm    *float64      `json:"distance_km"`
+	RequestedAt   string        `json:"requested_at"`
+	Trust         *TrustSummary `json:"trust"`
+}
+
+type CreateMatchingPolicyRequest struct {
+	SuccessWeight      float64 `json:"success_weight" binding:"min=0"`
+	InterestWeight     float64 `json:"interest_weight" binding:"min=0"`
+	DistanceWeight     float64 `json:"distance_weight" binding:"min=0"`
+	WaitWeight         float64 `json:"wait_weight" binding:"min=0"`
+	DistanceDecay      string  `json:"distance_decay" binding:"required,oneof=linear exponential"`
+	DistanceScaleKm    float64 `json:"distance_scale_km" binding:"required,gt=0"`
+	MissingDistanceKm  float64 `json:"missing_distance_km" binding:"min=0"`
+	WaitSaturationDays int     `json:"wait_saturation_days" binding:"min=0"`
+	AgingPointsPerDay  float64 `json:"aging_points_per_day" binding:"min=0"`
+	MaxWinsPerPeriod   int     `json:"max_wins_per_period" binding:"min=0"`
+	WinPeriodDays      int     `json:"win_period_days" binding:"min=0"`
+	TieBreak           string  `json:"tie_break" binding:"omitempty,oneof=oldest_request fewest_recent_wins lowest_success_score"`
+	Notes              string  `json:"notes"`
+}
@@ -1,12 +1,20 @@
 package dto
 
+// CreateUserReviewRequest reviews the other party of an exchange (a
+// reading_history entry returned by GET /reviews/pending).
 type CreateUserReviewRequest struct {
-	RevieweeID          string `json:"reviewee_id" binding:"required"`
-	BookID              string `json:"book_id"`
+	ExchangeID          string `json:"exchange_id" binding:"required"`
 	BehaviorRating      *int   `json:"behavior_rating" binding:"omitempty,min=1,max=5"`
 	BookConditionRating *int   `json:"book_condition_rating" binding:"omitempty,min=1,max=5"`
 	CommunicationRating *int   `json:"communication_rating" binding:"omitempty,min=1,max=5"`
-	Comment             string `json:"comment"`
+	Comment             string `json:"comment" binding:"max=2000"`
+}
+
+type UpdateUserReviewRequest struct {
+	BehaviorRating      *int    `json:"behavior_rating" binding:"omitempty,min=1,max=5"`
+	BookConditionRating *int    `json:"book_condition_rating" binding:"omitempty,min=1,max=5"`
+	CommunicationRating *int    `json:"communication_rating" binding:"omitempty,min=1,max=5"`
+	Comment             *string `json:"comment" binding:"omitempty,max=2000"`
 }
 
 type UserReviewResponse struct {
@@ -22,5 +30,35 @@ type UserReviewResponse struct {
 	CommunicationRating *int    `json:"communication_rating"`
 	AverageRating       float64 `json:"average_rating"`
 	Comment             string  `json:"comment"`
+	CommentHTML         string  `json:"comment_html"`
 	CreatedAt           string  `json:"created_at"`
 }
+
+type DimensionRating struct {
+	Average *float64 `json:"average"`
+	Count   int      `json:"count"`
+}
+
+// TrustSummary condenses the reviews a member has received. Rating is a
+// Bayesian average pulled towards the community mean, so it only drifts far
+// from it once a member has several reviews.
+type TrustSummary struct {
+	Rating        float64         `json:"rating"`
+	RawAverage    *float64        `json:"raw_average"`
+	ReviewCount   int             `json:"review_count"`
+	Behavior      DimensionRating `json:"behavior"`
+	BookCondition DimensionRating `json:"book_condition"`
+	Communication DimensionRating `json:"communication"`
+	RecentAverage *float64        `json:"recent_average"`
+	RecentCount   int             `json:"recent_count"`
+	Trend         string          `json:"trend"`
+}
+
+type CreateReviewDisputeRequest struct {
+	Statement string `json:"statement" binding:"required,min=10,max=2000"`
+}
+
+type ResolveReviewDisputeRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=uphold remove"`
+	Note     string `json:"note" binding:"max=2000"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+type ScoreRuleInput struct {
+	EventType     string `json:"event_type" binding:"required"`
+	Delta         int    `json:"delta"`
+	PeriodCap     int    `json:"period_cap" binding:"min=0"`
+	CapPeriodDays int    `json:"cap_period_days" binding:"min=0"`
+}
+
+type ScoreTierInput struct {
+	Level             int     `json:"level" binding:"min=1"`
+	Name              string  `json:"name" binding:"required,max=50"`
+	MinScore          int     `json:"min_score"`
+	MinCompletedLoans int     `json:"min_completed_loans" binding:"min=0"`
+	MaxActiveLoans    int     `json:"max_active_loans" binding:"min=1"`
+	MaxLoanDays       int     `json:"max_loan_days" binding:"min=1"`
+	MaxRenewals       int     `json:"max_renewals" binding:"min=0"`
+	PriorityBonus     float64 `json:"priority_bonus"`
+}
+
+// CreateScoreRuleSetRequest replaces the success score rules. Events left
+// out are worth nothing under the new version. Members who meet no tier's
+// thresholds fall into the lowest one.
+type CreateScoreRuleSetRequest struct {
+	StartingScore   int              `json:"starting_score" binding:"min=0"`
+	MinRequestScore int              `json:"min_request_score"`
+	Rules           []ScoreRuleInput `json:"rules" binding:"required,dive"`
+	Tiers           []ScoreTierInput `json:"tiers" binding:"required,min=1,dive"`
+	Notes           string           `json:"notes"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+type CreateTermRequest struct {
+	Kind     string   `json:"kind" binding:"required,oneof=category tag topic"`
+	Name     string   `json:"name" binding:"required,max=100"`
+	ParentID *string  `json:"parent_id"`
+	Aliases  []string `json:"aliases" binding:"omitempty,dive,max=100"`
+}
+
+// UpdateTermRequest renames or reparents a term. An empty parent_id moves
+// the term to the top level.
+type UpdateTermRequest struct {
+	Name     string  `json:"name" binding:"omitempty,max=100"`
+	ParentID *string `json:"parent_id"`
+}
+
+type AddAliasRequest struct {
+	Alias string `json:"alias" binding:"required,max=100"`
+}
+
+type MergeTermRequest struct {
+	IntoID string `json:"into_id" binding:"required"`
+}
@@ -2,11 +2,21 @@ package dto
 
 type UpdateProfileRequest struct {
 	FullName        string   `json:"full_name"`
-	Bio             string   `json:"bio"`
+	Bio             string   `json:"bio" binding:"max=2000"`
 	AvatarURL       string   `json:"avatar_url"`
 	LocationLat     *float64 `json:"location_lat"`
 	LocationLng     *float64 `json:"location_lng"`
 	LocationAddress string   `json:"location_address"`
+	District        string   `json:"district"`
+	LocationPrivacy string   `json:"location_privacy" binding:"omitempty,oneof=exact neighbourhood district hidden"`
+}
+
+// PublicLocation is a member's location as other members may see it.
+type PublicLocation struct {
+	Lat       float64 `json:"lat"`
+	Lng       float64 `json:"lng"`
+	District  string  `json:"district,omitempty"`
+	Precision string  `json:"precision"`
 }
 
 type UserPublicProfile struct {
@@ -15,6 +25,7 @@ type UserPublicProfile struct {
 	FullName        string `json:"full_name"`
 	AvatarURL       string `json:"avatar_url"`
 	Bio             string `json:"bio"`
+	BioHTML         string `json:"bio_html"`
 	SuccessScore    int    `json:"success_score"`
 	BooksShared     int    `json:"books_shared"`
 	BooksReceived   int    `json:"books_received"`
@@ -22,12 +33,40 @@ type UserPublicProfile struct {
 	IdeasPosted     int    `json:"ideas_posted"`
 	IsDonor         bool   `json:"is_donor"`
 	JoinedAt        string `json:"joined_at"`
+
+	Location *PublicLocation `json:"location,omitempty"`
+	Trust    *TrustSummary   `json:"trust,omitempty"`
+	Tier     *TierSummary    `json:"tier,omitempty"`
+	Badges   []BadgeSummary  `json:"badges,omitempty"`
+}
+
+// TierSummary is a member's score tier, what it lets them borrow and what
+// the next tier up takes.
+type TierSummary struct {
+	Level          int              `json:"level"`
+	Name           string           `json:"name"`
+	MaxActiveLoans int              `json:"max_active_loans"`
+	MaxLoanDays    int              `json:"max_loan_days"`
+	MaxRenewals    int              `json:"max_renewals"`
+	PriorityBonus  float64          `json:"priority_bonus"`
+	CompletedLoans int              `json:"completed_loans"`
+	Next           *NextTierSummary `json:"next,omitempty"`
+}
+
+type NextTierSummary struct {
+	Name              string `json:"name"`
+	MinScore          int    `json:"min_score"`
+	MinCompletedLoans int    `json:"min_completed_loans"`
 }
 
 type AddInterestsRequest struct {
 	Interests []string `json:"interests" binding:"required"`
 }
 
+type SetInterestWeightRequest struct {
+	Weight float64 `json:"weight" binding:"gt=0,lte=5"`
+}
+
 type LeaderboardResponse struct {
 	TopReaders     []UserPublicProfile `json:"top_readers"`
 	TopSharers     []UserPublicProfile `json:"top_sharers"`
@@ -35,3 +74,12 @@ type LeaderboardResponse struct {
 	HighestScores  []UserPublicProfile `json:"highest_scores"`
 	TopIdeaWriters []UserPublicProfile `json:"top_idea_writers"`
 }
+
+// BadgeSummary is a badge as shown on a member's profile.
+type BadgeSummary struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	AwardedAt   string `json:"awarded_at"`
+}
@@ -0,0 +1,182 @@
+package geocoding
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/bd_gazetteer.csv
+var gazetteerCSV string
+
+type place struct {
+	Location
+	keys []string
+}
+
+// Gazetteer is an offline geocoder backed by an embedded list of Bangladesh
+// divisions, districts and upazilas, plus the Dhaka city areas people give as
+// addresses. Upazila points are approximate headquarters, not boundaries.
+type Gazetteer struct {
+	places    []place
+	districts map[string]*place
+}
+
+func NewGazetteer() (*Gazetteer, error) {
+	records, err := csv.NewReader(strings.NewReader(gazetteerCSV)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gazetteer: %w", err)
+	}
+
+	g := &Gazetteer{districts: make(map[string]*place)}
+	for i, rec := range records[1:] {
+		if len(rec) != 7 {
+			return nil, fmt.Errorf("gazetteer line %d: expected 7 fields", i+2)
+		}
+		lat, err := strconv.ParseFloat(rec[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %d: %w", i+2, err)
+		}
+		lng, err := strconv.ParseFloat(rec[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %d: %w", i+2, err)
+		}
+
+		p := place{
+			Location: Location{
+				Lat:       lat,
+				Lng:       lng,
+				Name:      rec[1],
+				District:  rec[2],
+				Division:  rec[3],
+				Precision: Precision(rec[0]),
+				Source:    "gazetteer",
+			},
+			keys: []string{normalize(rec[1])},
+		}
+		if rec[6] != "" {
+			for _, alias := range strings.Split(rec[6], "|") {
+				p.keys = append(p.keys, normalize(alias))
+			}
+		}
+		g.places = append(g.places, p)
+	}
+
+	for i := range g.places {
+		p := &g.places[i]
+		if p.Precision == PrecisionDistrict {
+			for _, key := range p.keys {
+				g.districts[key] = p
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// Geocode returns the most specific place named in the address. Upazilas win
+// over districts, districts over divisions, and longer names over shorter ones.
+// Several upazilas share a name, so once the address names a district only
+// upazilas in that district are considered: "Sreepur, Magura" resolves to
+// Magura's Sreepur rather than Gazipur's.
+func (g *Gazetteer) Geocode(address string) (*Location, error) {
+	text := " " + normalize(address) + " "
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrNotFound
+	}
+
+	named := make(map[string]bool)
+	for key, p := range g.districts {
+		if strings.Contains(text, " "+key+" ") {
+			named[p.Name] = true
+		}
+	}
+
+	var best *place
+	bestRank, bestLen := 0, 0
+	for i := range g.places {
+		p := &g.places[i]
+		if p.Precision == PrecisionUpazila && len(named) > 0 && !named[p.District] {
+			continue
+		}
+		for _, key := range p.keys {
+			if !strings.Contains(text, " "+key+" ") {
+				continue
+			}
+			rank := precisionRank(p.Precision)
+			if rank > bestRank || (rank == bestRank && len(key) > bestLen) {
+				best, bestRank, bestLen = p, rank, len(key)
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, ErrNotFound
+	}
+	loc := best.Location
+	return &loc, nil
+}
+
+// District looks up a district by name or alias.
+func (g *Gazetteer) District(name string) (*Location, error) {
+	p, ok := g.districts[normalize(name)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	loc := p.Location
+	return &loc, nil
+}
+
+// Districts lists all districts, sorted by division then name.
+func (g *Gazetteer) Districts() []Location {
+	var out []Location
+	for _, p := range g.places {
+		if p.Precision == PrecisionDistrict {
+			out = append(out, p.Location)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Division != out[j].Division {
+			return out[i].Division < out[j].Division
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func precisionRank(p Precision) int {
+	switch p {
+	case PrecisionUpazila:
+		return 3
+	case PrecisionDistrict:
+		return 2
+	case PrecisionDivision:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// NearestDistrict returns the district whose centre is closest to the point.
+func (g *Gazetteer) NearestDistrict(lat, lng float64) *Location {
+	var best *place
+	bestDist := 0.0
+	for i := range g.places {
+		p := &g.places[i]
+		if p.Precision != PrecisionDistrict {
+			continue
+		}
+		d := DistanceKm(lat, lng, p.Lat, p.Lng)
+		if best == nil || d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	loc := best.Location
+	return &loc
+}
@@ -0,0 +1,99 @@
+package geocoding
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ErrNotFound is returned when an address cannot be resolved to coordinates.
+var ErrNotFound = errors.New("location not found")
+
+type Precision string
+
+const (
+	PrecisionExact    Precision = "exact"
+	PrecisionUpazila  Precision = "upazila"
+	PrecisionDistrict Precision = "district"
+	PrecisionDivision Precision = "division"
+)
+
+type Location struct {
+	Lat       float64   `json:"lat"`
+	Lng       float64   `json:"lng"`
+	Name      string    `json:"name"`
+	District  string    `json:"district,omitempty"`
+	Division  string    `json:"division,omitempty"`
+	Precision Precision `json:"precision"`
+	Source    string    `json:"source"`
+}
+
+// Geocoder resolves a free-text address to coordinates.
+type Geocoder interface {
+	Geocode(address string) (*Location, error)
+}
+
+// Chain tries each geocoder in order and returns the first match.
+type Chain []Geocoder
+
+func (c Chain) Geocode(address string) (*Location, error) {
+	for _, g := range c {
+		loc, err := g.Geocode(address)
+		if err == nil {
+			return loc, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Tolerance is how far (km) explicit coordinates may sit from a location of
+// this precision before the two are considered to disagree.
+func (p Precision) Tolerance() float64 {
+	switch p {
+	case PrecisionExact:
+		return 5
+	case PrecisionUpazila:
+		return 25
+	case PrecisionDistrict:
+		return 75
+	default:
+		return 250
+	}
+}
+
+// DistanceKm returns the great-circle distance between two points.
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371 // km
+
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+			math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// normalize lowercases text and collapses everything that is not a letter,
+// digit or combining mark (Bengali vowel signs) into single spaces.
+func normalize(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if r == '\'' || r == '’' {
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
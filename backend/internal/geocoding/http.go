@@ -0,0 +1,79 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// HTTPGeocoder queries a Nominatim-compatible search API, restricted to
+// Bangladesh.
+type HTTPGeocoder struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+}
+
+func NewHTTPGeocoder(baseURL, userAgent string) *HTTPGeocoder {
+	return &HTTPGeocoder{
+		baseURL:   baseURL,
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (h *HTTPGeocoder) Geocode(address string) (*Location, error) {
+	params := url.Values{}
+	params.Set("q", address)
+	params.Set("format", "json")
+	params.Set("countrycodes", "bd")
+	params.Set("limit", "1")
+
+	req, err := http.NewRequest(http.MethodGet, h.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", h.userAgent)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocoding request failed: %s", resp.Status)
+	}
+
+	var results []struct {
+		Lat         string `json:"lat"`
+		Lon         string `json:"lon"`
+		DisplayName string `json:"display_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode geocoding response: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return nil, err
+	}
+	lng, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Location{
+		Lat:       lat,
+		Lng:       lng,
+		Name:      results[0].DisplayName,
+		Precision: PrecisionExact,
+		Source:    "http",
+	}, nil
+}
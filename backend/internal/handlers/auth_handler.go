@@ -9,11 +9,12 @@ import (
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
+	authService   *services.AuthService
+	lendingPolicy *services.LendingPolicyService
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService *services.AuthService, lendingPolicy *services.LendingPolicyService) *AuthHandler {
+	return &AuthHandler{authService: authService, lendingPolicy: lendingPolicy}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -49,8 +50,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 func (h *AuthHandler) Me(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	c.JSON(http.StatusOK, dto.SuccessResponse("User info", gin.H{
-		"user_id": userID,
-	}))
+	userID := c.GetString("user_id")
+
+	user, err := h.authService.Me(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("User not found"))
+		return
+	}
+
+	standing, err := h.lendingPolicy.Standing(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to load tier"))
+		return
+	}
+	user.Tier = services.TierSummary(standing)
+	user.ActiveLoans = standing.ActiveLoans
+	user.CanRequest = &standing.CanRequest
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("User info", user))
 }
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+type BadgeHandler struct {
+	badgeRepo    *repository.BadgeRepository
+	achievements *services.AchievementService
+}
+
+func NewBadgeHandler(badgeRepo *repository.BadgeRepository, achievements *services.AchievementService) *BadgeHandler {
+	return &BadgeHandler{
+		badgeRepo:    badgeRepo,
+		achievements: achievements,
+	}
+}
+
+func (h *BadgeHandler) GetAll(c *gin.Context) {
+	badges, err := h.badgeRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch badges"))
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Badges retrieved successfully", badges))
+}
+
+// Create adds a badge. Members who already meet it get it the next time
+// the metric moves for them, or straight away with a backfill.
+func (h *BadgeHandler) Create(c *gin.Context) {
+	var req dto.CreateBadgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+	if !models.IsBadgeMetric(req.Metric) {
+		c.JSON(http.StatusBadRequest, dto.Error(fmt.Sprintf("unknown badge metric: %s", req.Metric)))
+		return
+	}
+
+	badge := &models.Badge{
+		Code:        req.Code,
+		Name:        req.Name,
+		Description: req.Description,
+		Icon:        sql.NullString{String: req.Icon, Valid: req.Icon != ""},
+		Metric:      models.BadgeMetric(req.Metric),
+		Threshold:   req.Threshold,
+		IsActive:    true,
+	}
+	if err := h.badgeRepo.Create(badge); err != nil {
+		if err == repository.ErrBadgeCodeTaken {
+			c.JSON(http.StatusConflict, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to create badge"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse("Badge created", badge))
+}
+
+func (h *BadgeHandler) Update(c *gin.Context) {
+	var req dto.UpdateBadgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	badge, err := h.badgeRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Badge not found"))
+		return
+	}
+
+	if req.Name != nil {
+		badge.Name = *req.Name
+	}
+	if req.Description != nil {
+		badge.Description = *req.Description
+	}
+	if req.Icon != nil {
+		badge.Icon = sql.NullString{String: *req.Icon, Valid: *req.Icon != ""}
+	}
+	if req.Threshold != nil {
+		badge.Threshold = *req.Threshold
+	}
+	if req.IsActive != nil {
+		badge.IsActive = *req.IsActive
+	}
+
+	if err := h.badgeRepo.Update(badge); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to update badge"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Badge updated", badge))
+}
+
+// Backfill awards badges members have already earned, optionally limited
+// with ?user_id=.
+func (h *BadgeHandler) Backfill(c *gin.Context) {
+	awards, err := h.achievements.Backfill(c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to backfill badges"))
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Badges backfilled", gin.H{"awarded": len(awards)}))
+}
@@ -2,26 +2,48 @@ package handlers
 
 import (
 	"database/sql"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/online-library/internal/dto"
 	"github.com/yourusername/online-library/internal/models"
 	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
 )
 
 type BookHandler struct {
-	bookRepo *repository.BookRepository
+	bookRepo        *repository.BookRepository
+	userRepo        *repository.UserRepository
+	taxonomyService *services.TaxonomyService
+	locationService *services.LocationService
 }
 
-func NewBookHandler(bookRepo *repository.BookRepository) *BookHandler {
-	return &BookHandler{bookRepo: bookRepo}
+func NewBookHandler(bookRepo *repository.BookRepository, userRepo *repository.UserRepository, taxonomyService *services.TaxonomyService, locationService *services.LocationService) *BookHandler {
+	return &BookHandler{
+		bookRepo:        bookRepo,
+		userRepo:        userRepo,
+		taxonomyService: taxonomyService,
+		locationService: locationService,
+	}
 }
 
+const (
+	defaultNearbyRadiusKm = 10
+	defaultNearbyLimit    = 50
+)
+
 func (h *BookHandler) GetAll(c *gin.Context) {
+	category, err := h.taxonomyService.Normalize(models.TermCategory, c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
 	filters := map[string]interface{}{
 		"status":   c.Query("status"),
-		"category": c.Query("category"),
+		"category": category,
 		"search":   c.Query("search"),
 	}
 
@@ -34,6 +56,55 @@ func (h *BookHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.SuccessResponse("Books retrieved successfully", books))
 }
 
+// GetNearby lists available books within radius_km of lat/lng. When no
+// coordinates are given the caller's saved profile location is used.
+func (h *BookHandler) GetNearby(c *gin.Context) {
+	var query dto.NearbyBooksQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	var lat, lng float64
+	switch {
+	case query.Lat != nil && query.Lng != nil:
+		lat, lng = *query.Lat, *query.Lng
+	case query.Lat == nil && query.Lng == nil:
+		user, err := h.userRepo.FindByID(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, dto.Error("User not found"))
+			return
+		}
+		if !user.LocationLat.Valid || !user.LocationLng.Valid {
+			c.JSON(http.StatusBadRequest, dto.Error("Set your location or pass lat and lng"))
+			return
+		}
+		lat, lng = user.LocationLat.Float64, user.LocationLng.Float64
+	default:
+		c.JSON(http.StatusBadRequest, dto.Error("lat and lng must be provided together"))
+		return
+	}
+
+	radiusKm := query.RadiusKm
+	if radiusKm == 0 {
+		radiusKm = defaultNearbyRadiusKm
+	}
+	radiusKm = math.Ceil(radiusKm/services.NearbyRadiusStepKm) * services.NearbyRadiusStepKm
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultNearbyLimit
+	}
+
+	books, err := h.bookRepo.FindNearby(h.locationService.NearbySearch(lat, lng, radiusKm, limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+	services.BucketNearby(books)
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Nearby books retrieved successfully", books))
+}
+
 func (h *BookHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
 
@@ -46,6 +117,24 @@ func (h *BookHandler) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.SuccessResponse("Book retrieved successfully", book))
 }
 
+// GetSimilar lists books related to this one, read from the precomputed
+// similarity table.
+func (h *BookHandler) GetSimilar(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 20 {
+		c.JSON(http.StatusBadRequest, dto.Error("limit must be between 1 and 20"))
+		return
+	}
+
+	books, err := h.bookRepo.FindSimilar(c.Param("id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Similar books retrieved successfully", books))
+}
+
 func (h *BookHandler) Create(c *gin.Context) {
 	var req dto.CreateBookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -76,6 +165,11 @@ func (h *BookHandler) Create(c *gin.Context) {
 		}
 	}
 
+	if err := h.taxonomyService.NormalizeBook(book); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
 	if err := h.bookRepo.Create(book); err != nil {
 		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
 		return
@@ -127,6 +221,11 @@ func (h *BookHandler) Update(c *gin.Context) {
 		book.Status = models.BookStatus(req.Status)
 	}
 
+	if err := h.taxonomyService.NormalizeBook(book); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
 	if err := h.bookRepo.Update(book); err != nil {
 		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
 		return
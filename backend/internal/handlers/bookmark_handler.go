@@ -1,20 +1,26 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/online-library/internal/dto"
 	"github.com/yourusername/online-library/internal/models"
 	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
 )
 
 type BookmarkHandler struct {
-	bookmarkRepo *repository.BookmarkRepository
+	bookmarkRepo    *repository.BookmarkRepository
+	interestService *services.InterestService
 }
 
-func NewBookmarkHandler(bookmarkRepo *repository.BookmarkRepository) *BookmarkHandler {
-	return &BookmarkHandler{bookmarkRepo: bookmarkRepo}
+func NewBookmarkHandler(bookmarkRepo *repository.BookmarkRepository, interestService *services.InterestService) *BookmarkHandler {
+	return &BookmarkHandler{
+		bookmarkRepo:    bookmarkRepo,
+		interestService: interestService,
+	}
 }
 
 func (h *BookmarkHandler) Create(c *gin.Context) {
@@ -38,6 +44,11 @@ func (h *BookmarkHandler) Create(c *gin.Context) {
 		return
 	}
 
+	// Learn from the bookmark
+	if err := h.interestService.RecordBookmark(userID, req.BookID, req.BookmarkType); err != nil {
+		log.Println("Failed to record bookmark interest:", err)
+	}
+
 	c.JSON(http.StatusCreated, bookmark)
 }
 
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+// Replies nested deeper than this are rejected
+const maxCommentDepth = 6
+
+const (
+	defaultCommentLimit = 20
+	maxMentions         = 10
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([\p{L}\p{N}_.\-]+)`)
+
+type CommentHandler struct {
+	commentRepo  *repository.CommentRepository
+	ideaRepo     *repository.IdeaRepository
+	userRepo     *repository.UserRepository
+	notifService *services.NotificationService
+}
+
+func NewCommentHandler(commentRepo *repository.CommentRepository, ideaRepo *repository.IdeaRepository, userRepo *repository.UserRepository, notifService *services.NotificationService) *CommentHandler {
+	return &CommentHandler{
+		commentRepo:  commentRepo,
+		ideaRepo:     ideaRepo,
+		userRepo:     userRepo,
+		notifService: notifService,
+	}
+}
+
+func (h *CommentHandler) GetByIdea(c *gin.Context) {
+	var query dto.CommentListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultCommentLimit
+	}
+
+	idea, err := h.ideaRepo.GetByID(c.Param("id"))
+	if err != nil || !ideaVisible(c, idea) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Idea not found"})
+		return
+	}
+
+	comments, total, err := h.commentRepo.ListThreads(idea.ID, query.Sort, query.Limit, query.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch comments"})
+		return
+	}
+
+	renderComments(comments)
+	c.JSON(http.StatusOK, dto.CommentListResponse{
+		Comments: comments,
+		Total:    total,
+		Limit:    query.Limit,
+		Offset:   query.Offset,
+	})
+}
+
+func (h *CommentHandler) Create(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req dto.CreateCommentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Comment cannot be empty"})
+		return
+	}
+
+	// Nobody, the author included, can comment on a hidden idea
+	idea, err := h.ideaRepo.GetByID(c.Param("id"))
+	if err != nil || idea.HiddenAt.Valid {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Idea not found"})
+		return
+	}
+
+	comment := &models.IdeaComment{
+		IdeaID:  idea.ID,
+		UserID:  userID,
+		Content: req.Content,
+	}
+
+	if req.ParentID != "" {
+		parent, err := h.commentRepo.FindByID(req.ParentID)
+		if err != nil || parent.IdeaID != idea.ID {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Parent comment not found on this idea"})
+			return
+		}
+		if parent.IsDeleted {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Cannot reply to a deleted comment"})
+			return
+		}
+		if parent.Depth+1 > maxCommentDepth {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "This thread is nested too deeply to reply to"})
+			return
+		}
+		comment.ParentID = sql.NullString{String: parent.ID, Valid: true}
+		comment.Depth = parent.Depth + 1
+	}
+
+	if err := h.commentRepo.Create(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to post comment"})
+		return
+	}
+
+	// The comment is saved, so a failed author lookup only leaves the
+	// username off the response
+	if author, err := h.userRepo.FindByID(userID); err == nil {
+		comment.Username = author.Username
+	} else {
+		log.Println("Failed to fetch comment author:", err)
+	}
+	h.notifyMentions(comment, nil)
+
+	renderComments([]*models.IdeaComment{comment})
+	c.JSON(http.StatusCreated, comment)
+}
+
+// Update edits a comment's text. Only members newly mentioned by the edit are
+// notified.
+func (h *CommentHandler) Update(c *gin.Context) {
+	comment, err := h.commentRepo.FindByID(c.Param("id"))
+	if err != nil || comment.IsDeleted {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Comment not found"})
+		return
+	}
+	if comment.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You can only edit your own comments"})
+		return
+	}
+
+	var req dto.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Comment cannot be empty"})
+		return
+	}
+
+	previous := extractMentions(comment.Content)
+	comment.Content = req.Content
+	if err := h.commentRepo.UpdateContent(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update comment"})
+		return
+	}
+
+	h.notifyMentions(comment, previous)
+
+	renderComments([]*models.IdeaComment{comment})
+	c.JSON(http.StatusOK, comment)
+}
+
+// Delete soft-deletes a comment. Authors can delete their own comments and
+// admins can delete any.
+func (h *CommentHandler) Delete(c *gin.Context) {
+	comment, err := h.commentRepo.FindByID(c.Param("id"))
+	if err != nil || comment.IsDeleted {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Comment not found"})
+		return
+	}
+	if comment.UserID != c.GetString("user_id") && c.GetString("user_role") != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You can only delete your own comments"})
+		return
+	}
+
+	if err := h.commentRepo.SoftDelete(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Comment deleted", nil))
+}
+
+func (h *CommentHandler) Vote(c *gin.Context) {
+	var req dto.VoteIdeaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	h.setVote(c, req.VoteType)
+}
+
+func (h *CommentHandler) Unvote(c *gin.Context) {
+	h.setVote(c, models.VoteNone)
+}
+
+func (h *CommentHandler) setVote(c *gin.Context, voteType string) {
+	change, err := h.commentRepo.Vote(c.Param("id"), c.GetString("user_id"), voteType)
+	if err != nil {
+		switch err {
+		case repository.ErrSelfCommentVote:
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+		case repository.ErrCommentNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Comment not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to vote"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Vote recorded", change))
+}
+
+// notifyMentions notifies members @mentioned in the comment, skipping the
+// author and anyone listed in alreadyNotified.
+func (h *CommentHandler) notifyMentions(comment *models.IdeaComment, alreadyNotified []string) {
+	if comment == nil {
+		return
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range alreadyNotified {
+		skip[name] = true
+	}
+	var names []string
+	for _, name := range extractMentions(comment.Content) {
+		if !skip[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	users, err := h.userRepo.FindByUsernames(names)
+	if err != nil {
+		return
+	}
+	for _, user := range users {
+		if user.ID != comment.UserID {
+			h.notifService.NotifyMention(user.ID, comment.Username, comment.IdeaID, comment.ID)
+		}
+	}
+}
+
+// extractMentions returns the distinct lowercased usernames @mentioned in
+// text, capped at maxMentions.
+func extractMentions(text string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
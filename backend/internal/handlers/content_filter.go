@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/contentfilter"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+// What a member is told when the content filter rejects their post
+var rejectMessages = map[string]string{
+	contentfilter.ReasonProfanity: "Your post contains language that isn't allowed",
+	contentfilter.ReasonLinks:     "Your post contains too many links",
+	contentfilter.ReasonDuplicate: "You have already posted this text",
+	contentfilter.ReasonRateLimit: "You are posting too often, please wait a while",
+}
+
+// screenPost runs the content filter over a post about to be saved. When it
+// returns false the post was rejected and the response has been written.
+func screenPost(c *gin.Context, filter *services.ContentFilterService, post contentfilter.Post) (contentfilter.Result, bool) {
+	result, err := filter.Check(post)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to check content"})
+		return result, false
+	}
+	if result.Verdict != contentfilter.Reject {
+		return result, true
+	}
+
+	reason := result.Reasons[0]
+	status := http.StatusUnprocessableEntity
+	if reason == contentfilter.ReasonRateLimit {
+		status = http.StatusTooManyRequests
+	}
+	c.JSON(status, dto.ErrorResponse{Error: rejectMessages[reason], Code: reason})
+	return result, false
+}
@@ -14,13 +14,15 @@ import (
 type DonationHandler struct {
 	donationRepo *repository.DonationRepository
 	scoreService *services.SuccessScoreService
+	achievements *services.AchievementService
 	db           *sql.DB
 }
 
-func NewDonationHandler(donationRepo *repository.DonationRepository, scoreService *services.SuccessScoreService, db *sql.DB) *DonationHandler {
+func NewDonationHandler(donationRepo *repository.DonationRepository, scoreService *services.SuccessScoreService, achievements *services.AchievementService, db *sql.DB) *DonationHandler {
 	return &DonationHandler{
 		donationRepo: donationRepo,
 		scoreService: scoreService,
+		achievements: achievements,
 		db:           db,
 	}
 }
@@ -63,7 +65,9 @@ func (h *DonationHandler) Create(c *gin.Context) {
 	} else {
 		h.scoreService.ProcessMoneyDonation(donorID, donation.ID)
 	}
+	h.achievements.Donated(donorID)
 
+	renderDonations(donation)
 	c.JSON(http.StatusCreated, donation)
 }
 
@@ -73,5 +77,6 @@ func (h *DonationHandler) GetAll(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch donations"})
 		return
 	}
+	renderDonations(donations...)
 	c.JSON(http.StatusOK, donations)
 }
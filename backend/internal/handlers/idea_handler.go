@@ -1,29 +1,63 @@
 package handlers
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/contentfilter"
 	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/markdown"
 	"github.com/yourusername/online-library/internal/models"
 	"github.com/yourusername/online-library/internal/repository"
 	"github.com/yourusername/online-library/internal/services"
+	"github.com/yourusername/online-library/internal/textdiff"
 )
 
+// Unchanged lines shown around each change in revision diffs
+const ideaDiffContext = 2
+
 type IdeaHandler struct {
-	ideaRepo     *repository.IdeaRepository
-	scoreService *services.SuccessScoreService
-	notifService *services.NotificationService
+	ideaRepo        *repository.IdeaRepository
+	scoreService    *services.SuccessScoreService
+	notifService    *services.NotificationService
+	auditService    *services.AuditService
+	taxonomyService *services.TaxonomyService
+	filterService   *services.ContentFilterService
+	achievements    *services.AchievementService
 }
 
-func NewIdeaHandler(ideaRepo *repository.IdeaRepository, scoreService *services.SuccessScoreService, notifService *services.NotificationService) *IdeaHandler {
+func NewIdeaHandler(ideaRepo *repository.IdeaRepository, scoreService *services.SuccessScoreService, notifService *services.NotificationService, auditService *services.AuditService, taxonomyService *services.TaxonomyService, filterService *services.ContentFilterService, achievements *services.AchievementService) *IdeaHandler {
 	return &IdeaHandler{
-		ideaRepo:     ideaRepo,
-		scoreService: scoreService,
-		notifService: notifService,
+		ideaRepo:        ideaRepo,
+		scoreService:    scoreService,
+		notifService:    notifService,
+		auditService:    auditService,
+		taxonomyService: taxonomyService,
+		filterService:   filterService,
+		achievements:    achievements,
 	}
 }
 
+const (
+	defaultFeedLimit      = 20
+	defaultHighlightLimit = 10
+)
+
+// How far back each "top" period reaches
+var feedPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
 func (h *IdeaHandler) Create(c *gin.Context) {
 	userID := c.GetString("user_id")
 	var req dto.CreateIdeaRequest
@@ -34,57 +68,433 @@ func (h *IdeaHandler) Create(c *gin.Context) {
 	}
 
 	idea := &models.ReadingIdea{
-		BookID:  req.BookID,
-		UserID:  userID,
-		Title:   req.Title,
-		Content: req.Content,
+		BookID:    req.BookID,
+		UserID:    userID,
+		Title:     req.Title,
+		Content:   req.Content,
+		Chapter:   strings.TrimSpace(req.Chapter),
+		PageStart: nullInt(req.PageStart),
+		PageEnd:   nullInt(req.PageEnd),
+		Quote:     strings.TrimSpace(req.Quote),
+	}
+	if err := validateIdeaAnchor(idea); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	post := ideaPost(idea)
+	result, ok := screenPost(c, h.filterService, post)
+	if !ok {
+		return
 	}
 
 	if err := h.ideaRepo.Create(idea); err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create idea"})
 		return
 	}
+	h.filterService.Record(post, idea.ID, result)
 
 	// Update success score
 	h.scoreService.ProcessIdeaPosted(userID, idea.ID)
 
+	renderIdeas(idea)
 	c.JSON(http.StatusCreated, idea)
 }
 
 func (h *IdeaHandler) GetByBook(c *gin.Context) {
 	bookID := c.Param("id")
-	ideas, err := h.ideaRepo.GetByBook(bookID, 50, 0)
+
+	var query dto.IdeaListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	filter := repository.IdeaAnchorFilter{Chapter: strings.TrimSpace(query.Chapter), Page: query.Page}
+	ideas, err := h.ideaRepo.GetByBook(bookID, filter, 50, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch ideas"})
 		return
 	}
+	renderIdeas(ideas...)
 	c.JSON(http.StatusOK, ideas)
 }
 
-func (h *IdeaHandler) Vote(c *gin.Context) {
+// GetHighlights lists the most upvoted quotes from a book's ideas.
+func (h *IdeaHandler) GetHighlights(c *gin.Context) {
+	var query dto.HighlightQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultHighlightLimit
+	}
+
+	highlights, err := h.ideaRepo.GetHighlights(c.Param("id"), strings.TrimSpace(query.Chapter), query.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch highlights"})
+		return
+	}
+	c.JSON(http.StatusOK, highlights)
+}
+
+// GetFeed lists ideas across all books, ranked by the sort mode and paged
+// with an opaque cursor.
+func (h *IdeaHandler) GetFeed(c *gin.Context) {
+	h.feed(c, "")
+}
+
+// GetMyFeed is the feed restricted to books the caller has read or
+// bookmarked.
+func (h *IdeaHandler) GetMyFeed(c *gin.Context) {
+	h.feed(c, c.GetString("user_id"))
+}
+
+func (h *IdeaHandler) feed(c *gin.Context, forUserID string) {
+	var query dto.IdeaFeedQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	filter := repository.IdeaFeedFilter{
+		Sort:      query.Sort,
+		BookID:    query.BookID,
+		AuthorID:  query.AuthorID,
+		ForUserID: forUserID,
+		Limit:     query.Limit,
+	}
+	if filter.Sort == "" {
+		filter.Sort = "hot"
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultFeedLimit
+	}
+	if period, ok := feedPeriods[query.Period]; ok && filter.Sort == "top" {
+		since := time.Now().Add(-period)
+		filter.Since = &since
+	}
+	if query.Topic != "" {
+		topic, err := h.taxonomyService.Normalize(models.TermTopic, query.Topic)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch ideas"})
+			return
+		}
+		filter.Topic = topic
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeIdeaCursor(query.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid cursor"})
+			return
+		}
+		filter.After = cursor
+	}
+
+	ideas, next, err := h.ideaRepo.Feed(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch ideas"})
+		return
+	}
+
+	response := dto.IdeaFeedResponse{Ideas: make([]dto.IdeaResponse, len(ideas))}
+	for i, idea := range ideas {
+		response.Ideas[i] = dto.IdeaResponse{
+			ID:           idea.ID,
+			BookID:       idea.BookID,
+			BookTitle:    idea.Book.Title,
+			UserID:       idea.UserID,
+			Username:     idea.User.Username,
+			Title:        idea.Title,
+			Content:      idea.Content,
+			ContentHTML:  markdown.Render(idea.Content),
+			Chapter:      idea.Chapter,
+			PageStart:    intPtr(idea.PageStart),
+			PageEnd:      intPtr(idea.PageEnd),
+			Quote:        idea.Quote,
+			Upvotes:      idea.Upvotes,
+			Downvotes:    idea.Downvotes,
+			NetScore:     idea.Upvotes - idea.Downvotes,
+			CommentCount: idea.CommentCount,
+			Edited:       idea.EditedAt.Valid,
+			CreatedAt:    idea.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	if next != nil {
+		response.NextCursor = encodeIdeaCursor(next)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func intPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+func encodeIdeaCursor(cursor *repository.IdeaCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeIdeaCursor(s string) (*repository.IdeaCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	cursor := &repository.IdeaCursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil {
+		return nil, err
+	}
+	if _, err := strconv.ParseFloat(cursor.Score, 64); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	return cursor, nil
+}
+
+// Update lets the author edit an idea. Each edit is kept as a revision with
+// line diffs against the previous version.
+func (h *IdeaHandler) Update(c *gin.Context) {
 	userID := c.GetString("user_id")
-	ideaID := c.Param("id")
-	var req dto.VoteIdeaRequest
 
+	idea, err := h.ideaRepo.GetByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Idea not found"})
+		return
+	}
+	if idea.UserID != userID {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You can only edit your own ideas"})
+		return
+	}
+
+	var req dto.UpdateIdeaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rev := &models.IdeaRevision{
+		IdeaID:   idea.ID,
+		Title:    idea.Title,
+		Content:  idea.Content,
+		EditedBy: sql.NullString{String: userID, Valid: true},
+	}
+	if req.Title != nil {
+		rev.Title = *req.Title
+	}
+	if req.Content != nil {
+		rev.Content = *req.Content
+	}
+
+	before := *idea
+	anchorChanged := applyAnchorUpdate(idea, &req)
+	if err := validateIdeaAnchor(idea); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	quoteChanged := idea.Quote != before.Quote
+	if rev.Title == idea.Title && rev.Content == idea.Content && !quoteChanged {
+		if anchorChanged {
+			if err := h.ideaRepo.Update(idea, nil); err != nil {
+				c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update idea"})
+				return
+			}
+		}
+		renderIdeas(idea)
+		c.JSON(http.StatusOK, idea)
+		return
+	}
+
+	post := ideaPost(&models.ReadingIdea{ID: idea.ID, UserID: userID, Title: rev.Title, Content: rev.Content, Quote: idea.Quote})
+	result, ok := screenPost(c, h.filterService, post)
+	if !ok {
+		return
+	}
+
+	// A quote-only edit leaves title and content, and so the revision
+	// history, as they were
+	if rev.Title == idea.Title && rev.Content == idea.Content {
+		rev = nil
+	} else {
+		rev.TitleDiff = textdiff.Unified(idea.Title, rev.Title, ideaDiffContext)
+		rev.ContentDiff = textdiff.Unified(idea.Content, rev.Content, ideaDiffContext)
+		idea.Title, idea.Content = rev.Title, rev.Content
+	}
+
+	if err := h.ideaRepo.Update(idea, rev); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update idea"})
+		return
+	}
+	h.filterService.Record(post, idea.ID, result)
+
+	renderIdeas(idea)
+	c.JSON(http.StatusOK, idea)
+}
+
+// ideaPost is the text of an idea the content filter screens.
+func ideaPost(idea *models.ReadingIdea) contentfilter.Post {
+	text := idea.Title + "\n\n" + idea.Content
+	if idea.Quote != "" {
+		text += "\n\n" + idea.Quote
+	}
+	return contentfilter.Post{UserID: idea.UserID, Kind: services.PostIdea, ResourceID: idea.ID, Text: text}
+}
+
+// applyAnchorUpdate copies the anchor fields present in req onto idea and
+// reports whether any of them changed.
+func applyAnchorUpdate(idea *models.ReadingIdea, req *dto.UpdateIdeaRequest) bool {
+	before := [4]interface{}{idea.Chapter, idea.PageStart, idea.PageEnd, idea.Quote}
+	if req.Chapter != nil {
+		idea.Chapter = strings.TrimSpace(*req.Chapter)
+	}
+	if req.PageStart != nil {
+		idea.PageStart = sql.NullInt64{Int64: int64(*req.PageStart), Valid: *req.PageStart > 0}
+	}
+	if req.PageEnd != nil {
+		idea.PageEnd = sql.NullInt64{Int64: int64(*req.PageEnd), Valid: *req.PageEnd > 0}
+	}
+	if req.Quote != nil {
+		idea.Quote = strings.TrimSpace(*req.Quote)
+	}
+	return before != [4]interface{}{idea.Chapter, idea.PageStart, idea.PageEnd, idea.Quote}
+}
+
+// validateIdeaAnchor checks that a page range has a start and runs forwards.
+func validateIdeaAnchor(idea *models.ReadingIdea) error {
+	if idea.PageEnd.Valid && !idea.PageStart.Valid {
+		return fmt.Errorf("page_end requires page_start")
+	}
+	if idea.PageEnd.Valid && idea.PageEnd.Int64 < idea.PageStart.Int64 {
+		return fmt.Errorf("page_end must not be before page_start")
+	}
+	return nil
+}
+
+// Delete removes an idea. Authors can delete their own; admins can delete
+// any, which is recorded in the audit log. The posting award is taken back.
+func (h *IdeaHandler) Delete(c *gin.Context) {
+	userID := c.GetString("user_id")
+	isAdmin := c.GetString("user_role") == string(models.RoleAdmin)
+
+	idea, err := h.ideaRepo.GetByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Idea not found"})
+		return
+	}
+	if idea.UserID != userID && !isAdmin {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You can only delete your own ideas"})
+		return
+	}
+
+	if err := h.ideaRepo.Delete(idea, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete idea"})
+		return
+	}
+
+	h.scoreService.ReverseIdeaPosted(idea.UserID, idea.ID)
+
+	if idea.UserID != userID {
+		h.auditService.Log(services.AuditEntry{
+			UserID:       userID,
+			Action:       "idea.deleted",
+			ResourceType: "idea",
+			ResourceID:   idea.ID,
+			Details:      map[string]interface{}{"author_id": idea.UserID, "title": idea.Title},
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Idea deleted", nil))
+}
+
+// ideaVisible reports whether the caller may see an idea. A hidden idea is
+// only shown to its author and to moderators.
+func ideaVisible(c *gin.Context, idea *models.ReadingIdea) bool {
+	if !idea.HiddenAt.Valid || idea.UserID == c.GetString("user_id") {
+		return true
+	}
+	role := c.GetString("user_role")
+	return role == string(models.RoleAdmin) || role == string(models.RoleModerator)
+}
+
+// GetRevisions returns an idea's edit history. A hidden idea's history is
+// only shown to its author and to moderators.
+func (h *IdeaHandler) GetRevisions(c *gin.Context) {
+	idea, err := h.ideaRepo.GetByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Idea not found"})
+		return
+	}
+	if !ideaVisible(c, idea) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Idea not found"})
+		return
+	}
+
+	revisions, err := h.ideaRepo.GetRevisions(idea.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch revisions"})
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// Vote sets, switches or withdraws the caller's vote on an idea. Repeating
+// the current vote changes nothing; otherwise the author's score moves by
+// the net difference.
+func (h *IdeaHandler) Vote(c *gin.Context) {
+	var req dto.VoteIdeaRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	if err := h.ideaRepo.Vote(ideaID, userID, req.VoteType); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to vote"})
+	h.setVote(c, req.VoteType)
+}
+
+// Unvote withdraws the caller's vote on an idea.
+func (h *IdeaHandler) Unvote(c *gin.Context) {
+	h.setVote(c, models.VoteNone)
+}
+
+func (h *IdeaHandler) setVote(c *gin.Context, voteType string) {
+	ideaID := c.Param("id")
+
+	change, err := h.ideaRepo.Vote(ideaID, c.GetString("user_id"), voteType, func(tx *sql.Tx, change *models.VoteChange) error {
+		return h.scoreService.ProcessIdeaVoteChangeTx(tx, change.AuthorID, ideaID, change.Previous, change.Current)
+	})
+	if err != nil {
+		switch {
+		case err == repository.ErrSelfVote:
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+		case err == repository.ErrIdeaNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Idea not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to vote"})
+		}
 		return
 	}
 
-	// Get idea author
-	idea, _ := h.ideaRepo.GetByID(ideaID)
-	if idea != nil && idea.UserID != userID {
-		if req.VoteType == "upvote" {
-			h.scoreService.ProcessIdeaUpvote(idea.UserID, ideaID)
-		} else {
-			h.scoreService.ProcessIdeaDownvote(idea.UserID, ideaID)
+	if change.Previous != change.Current {
+		if change.Current != models.VoteNone {
+			h.notifService.NotifyIdeaVote(change.AuthorID, change.VoterName, change.IdeaTitle, change.Current == models.VoteUp)
+		}
+		if change.Current == models.VoteUp {
+			h.achievements.IdeaVoted(change.AuthorID)
 		}
 	}
 
-	c.JSON(http.StatusOK, dto.SuccessResponse("Vote recorded", nil))
+	c.JSON(http.StatusOK, dto.SuccessResponse("Vote recorded", change))
+}
+
+func nullInt(v *int) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*v), Valid: true}
 }
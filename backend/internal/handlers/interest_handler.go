@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+type InterestHandler struct {
+	interestRepo    *repository.InterestRepository
+	matchingService *services.MatchingService
+	taxonomyService *services.TaxonomyService
+}
+
+func NewInterestHandler(interestRepo *repository.InterestRepository, matchingService *services.MatchingService, taxonomyService *services.TaxonomyService) *InterestHandler {
+	return &InterestHandler{
+		interestRepo:    interestRepo,
+		matchingService: matchingService,
+		taxonomyService: taxonomyService,
+	}
+}
+
+func (h *InterestHandler) GetMine(c *gin.Context) {
+	interests, err := h.interestRepo.GetByUser(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch interests"})
+		return
+	}
+	c.JSON(http.StatusOK, interests)
+}
+
+func (h *InterestHandler) SetWeight(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req dto.SetInterestWeightRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	interest, err := h.taxonomyService.Normalize(models.TermTopic, c.Param("interest"))
+	if err != nil || interest == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid interest"})
+		return
+	}
+
+	if err := h.interestRepo.SetWeight(userID, interest, req.Weight); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update interest"})
+		return
+	}
+
+	if _, err := h.matchingService.RecalculatePriorities(services.RescoreScope{UserID: userID}); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update request priorities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Interest updated", nil))
+}
+
+func (h *InterestHandler) Delete(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	interest, err := h.taxonomyService.Normalize(models.TermTopic, c.Param("interest"))
+	if err != nil || interest == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid interest"})
+		return
+	}
+
+	if err := h.interestRepo.Delete(userID, interest); err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, err := h.matchingService.RecalculatePriorities(services.RescoreScope{UserID: userID}); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update request priorities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Interest removed", nil))
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+type LendingHandler struct {
+	requestRepo     *repository.RequestRepository
+	bookRepo        *repository.BookRepository
+	lendingPolicy   *services.LendingPolicyService
+	matchingService *services.MatchingService
+	scoreService    *services.SuccessScoreService
+	notifService    *services.NotificationService
+	achievements    *services.AchievementService
+}
+
+func NewLendingHandler(requestRepo *repository.RequestRepository, bookRepo *repository.BookRepository, lendingPolicy *services.LendingPolicyService, matchingService *services.MatchingService, scoreService *services.SuccessScoreService, notifService *services.NotificationService, achievements *services.AchievementService) *LendingHandler {
+	return &LendingHandler{
+		requestRepo:     requestRepo,
+		bookRepo:        bookRepo,
+		lendingPolicy:   lendingPolicy,
+		matchingService: matchingService,
+		scoreService:    scoreService,
+		notifService:    notifService,
+		achievements:    achievements,
+	}
+}
+
+// policyDenied writes a 403 when err is a lending policy decision. It
+// returns false for any other error, which the caller reports itself.
+func policyDenied(c *gin.Context, err error) bool {
+	if perr, ok := err.(*services.PolicyError); ok {
+		c.JSON(http.StatusForbidden, dto.Error(perr.Message))
+		return true
+	}
+	return false
+}
+
+// Request files a request for a book, if the member's tier allows another loan.
+func (h *LendingHandler) Request(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	book, err := h.bookRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Book not found"))
+		return
+	}
+	if book.CurrentHolderID.Valid && book.CurrentHolderID.String == userID {
+		c.JSON(http.StatusBadRequest, dto.Error("You already have this book"))
+		return
+	}
+
+	if _, err := h.lendingPolicy.CheckRequest(userID); err != nil {
+		if !policyDenied(c, err) {
+			c.JSON(http.StatusInternalServerError, dto.Error("Failed to check lending policy"))
+		}
+		return
+	}
+
+	req := &models.BookRequest{BookID: book.ID, UserID: userID}
+	if err := h.requestRepo.Create(req); err != nil {
+		if err == repository.ErrAlreadyRequested {
+			c.JSON(http.StatusConflict, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to request book"))
+		return
+	}
+
+	if err := h.matchingService.UpdateRequestPriorities(book.ID); err != nil {
+		log.Println("Failed to rescore requests:", err)
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse("Book requested", req))
+}
+
+// Process lets the holder of a book approve or reject a pending request.
+// Only the top-ranked eligible request can be approved, and only once any
+// current loan of the book has been returned. Approving hands the book over
+// for as long as the requester's tier allows, or for due_days if the holder
+// asks for a shorter loan.
+func (h *LendingHandler) Process(c *gin.Context) {
+	var input dto.ProcessRequestRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	req, err := h.requestRepo.FindByID(input.RequestID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Request not found"))
+		return
+	}
+	if req.Status != "pending" {
+		c.JSON(http.StatusConflict, dto.Error("This request has already been processed"))
+		return
+	}
+
+	book, err := h.bookRepo.FindByID(req.BookID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Book not found"))
+		return
+	}
+	owner := book.CurrentHolderID
+	if !owner.Valid {
+		owner = book.CreatedBy
+	}
+	if owner.String != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, dto.Error("Only the current holder can process requests for this book"))
+		return
+	}
+
+	if input.Action == "reject" {
+		if err := h.requestRepo.Reject(req); err != nil {
+			c.JSON(http.StatusInternalServerError, dto.Error("Failed to reject request"))
+			return
+		}
+		h.notifService.NotifyRequestRejected(req.UserID, book.ID, book.Title)
+		c.JSON(http.StatusOK, dto.SuccessResponse("Request rejected", req))
+		return
+	}
+
+	standing, err := h.lendingPolicy.CheckLoan(req.UserID)
+	if err != nil {
+		if !policyDenied(c, err) {
+			c.JSON(http.StatusInternalServerError, dto.Error("Failed to check lending policy"))
+		}
+		return
+	}
+
+	// The book goes to the top-ranked eligible request, so the policy's win
+	// cap and tie-break decide real allocations and not just the simulator's
+	best, err := h.matchingService.SelectBestMatch(book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to rank requests"))
+		return
+	}
+	if best != req.ID {
+		c.JSON(http.StatusConflict, dto.Error("Another request ranks ahead of this one. Approve the top-ranked request or reject the ones ahead of it"))
+		return
+	}
+
+	dueDate := services.LoanDueDate(standing.Tier, input.DueDays, time.Now())
+	if err := h.requestRepo.Approve(req, owner.String, dueDate); err != nil {
+		if err == repository.ErrBookOnLoan {
+			c.JSON(http.StatusConflict, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to approve request"))
+		return
+	}
+	h.notifService.NotifyRequestApproved(req.UserID, book.ID, book.Title)
+	h.achievements.BookHandedOver(owner.String, book.CreatedBy.String)
+
+	if err := h.matchingService.UpdateRequestPriorities(book.ID); err != nil {
+		log.Println("Failed to rescore requests:", err)
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Request approved", req))
+}
+
+// Return ends the member's loan of a book and scores it as on time or late.
+func (h *LendingHandler) Return(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	loan, err := h.requestRepo.FindLoan(c.Param("id"), userID)
+	if err != nil {
+		if err == repository.ErrLoanNotFound {
+			c.JSON(http.StatusNotFound, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch loan"))
+		return
+	}
+
+	if err := h.requestRepo.Return(loan); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to return book"))
+		return
+	}
+
+	if loan.DueDate.Valid && loan.ReturnedAt.Time.After(loan.DueDate.Time) {
+		err = h.scoreService.ProcessReturnLate(userID, loan.BookID)
+	} else {
+		err = h.scoreService.ProcessReturnOnTime(userID, loan.BookID)
+		h.achievements.BookReturned(userID)
+	}
+	if err != nil {
+		log.Println("Failed to score return:", err)
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Book returned", loan))
+}
+
+// Renew extends the member's loan of a book, within their tier's renewal limit.
+func (h *LendingHandler) Renew(c *gin.Context) {
+	loan, err := h.requestRepo.FindLoan(c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		if err == repository.ErrLoanNotFound {
+			c.JSON(http.StatusNotFound, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch loan"))
+		return
+	}
+
+	dueDate, err := h.lendingPolicy.CheckRenewal(loan)
+	if err != nil {
+		if !policyDenied(c, err) {
+			c.JSON(http.StatusInternalServerError, dto.Error("Failed to check lending policy"))
+		}
+		return
+	}
+
+	if err := h.requestRepo.Renew(loan, dueDate); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to renew loan"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Loan renewed", loan))
+}
+
+// GetStanding shows the member their tier and what it lets them borrow.
+func (h *LendingHandler) GetStanding(c *gin.Context) {
+	standing, err := h.lendingPolicy.Standing(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch standing"))
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Standing retrieved successfully", standing))
+}
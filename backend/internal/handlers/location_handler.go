@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+type LocationHandler struct {
+	locationService *services.LocationService
+}
+
+func NewLocationHandler(locationService *services.LocationService) *LocationHandler {
+	return &LocationHandler{locationService: locationService}
+}
+
+func (h *LocationHandler) GetDistricts(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.SuccessResponse("Districts retrieved successfully", h.locationService.Districts()))
+}
+
+func (h *LocationHandler) Geocode(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		c.JSON(http.StatusBadRequest, dto.Error("address is required"))
+		return
+	}
+
+	location, err := h.locationService.Geocode(address)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Location not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Location resolved successfully", location))
+}
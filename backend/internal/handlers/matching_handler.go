@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+type MatchingHandler struct {
+	policyRepo      *repository.MatchingPolicyRepository
+	requestRepo     *repository.RequestRepository
+	bookRepo        *repository.BookRepository
+	userRepo        *repository.UserRepository
+	matchingService *services.MatchingService
+	trustService    *services.TrustService
+}
+
+func NewMatchingHandler(policyRepo *repository.MatchingPolicyRepository, requestRepo *repository.RequestRepository, bookRepo *repository.BookRepository, userRepo *repository.UserRepository, matchingService *services.MatchingService, trustService *services.TrustService) *MatchingHandler {
+	return &MatchingHandler{
+		policyRepo:      policyRepo,
+		requestRepo:     requestRepo,
+		bookRepo:        bookRepo,
+		userRepo:        userRepo,
+		matchingService: matchingService,
+		trustService:    trustService,
+	}
+}
+
+func (h *MatchingHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.policyRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch matching policies"))
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Matching policies retrieved successfully", policies))
+}
+
+func (h *MatchingHandler) GetActivePolicy(c *gin.Context) {
+	policy, err := h.policyRepo.GetActive()
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Active matching policy", policy))
+}
+
+// CreatePolicy stores a new policy version and activates it. Existing
+// request scores keep the version they were computed with until rescored.
+func (h *MatchingHandler) CreatePolicy(c *gin.Context) {
+	var req dto.CreateMatchingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	if req.SuccessWeight+req.InterestWeight+req.DistanceWeight+req.WaitWeight == 0 {
+		c.JSON(http.StatusBadRequest, dto.Error("at least one weight must be positive"))
+		return
+	}
+	if req.MaxWinsPerPeriod > 0 && req.WinPeriodDays == 0 {
+		c.JSON(http.StatusBadRequest, dto.Error("win_period_days is required when max_wins_per_period is set"))
+		return
+	}
+	if req.TieBreak == "" {
+		req.TieBreak = string(models.TieBreakOldestRequest)
+	}
+
+	policy := &models.MatchingPolicy{
+		SuccessWeight:      req.SuccessWeight,
+		InterestWeight:     req.InterestWeight,
+		DistanceWeight:     req.DistanceWeight,
+		WaitWeight:         req.WaitWeight,
+		DistanceDecay:      models.DistanceDecay(req.DistanceDecay),
+		DistanceScaleKm:    req.DistanceScaleKm,
+		MissingDistanceKm:  req.MissingDistanceKm,
+		WaitSaturationDays: req.WaitSaturationDays,
+		AgingPointsPerDay:  req.AgingPointsPerDay,
+		MaxWinsPerPeriod:   req.MaxWinsPerPeriod,
+		WinPeriodDays:      req.WinPeriodDays,
+		TieBreak:           models.TieBreak(req.TieBreak),
+		Notes:              req.Notes,
+		CreatedBy:          sql.NullString{String: c.GetString("user_id"), Valid: true},
+	}
+
+	if err := h.policyRepo.Create(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to save matching policy"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse("Matching policy created", policy))
+}
+
+func (h *MatchingHandler) ActivatePolicy(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error("invalid policy version"))
+		return
+	}
+
+	if err := h.policyRepo.Activate(version); err != nil {
+		c.JSON(http.StatusNotFound, dto.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Matching policy activated", nil))
+}
+
+// Rescore recalculates pending request priorities under the active policy,
+// optionally limited with ?book_id= or ?user_id=.
+func (h *MatchingHandler) Rescore(c *gin.Context) {
+	scope := services.RescoreScope{
+		BookID: c.Query("book_id"),
+		UserID: c.Query("user_id"),
+	}
+
+	count, err := h.matchingService.RecalculatePriorities(scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Request priorities recalculated", gin.H{"rescored": count}))
+}
+
+// GetRequestScore shows a requester where their request ranks and how its
+// priority score breaks down.
+func (h *MatchingHandler) GetRequestScore(c *gin.Context) {
+	req, err := h.requestRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Request not found"))
+		return
+	}
+
+	if req.UserID != c.GetString("user_id") && c.GetString("user_role") != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, dto.Error("You can only view your own requests"))
+		return
+	}
+
+	response := dto.RequestScoreResponse{
+		RequestID:          req.ID,
+		BookID:             req.BookID,
+		Status:             req.Status,
+		PriorityScore:      req.PriorityScore,
+		InterestMatchScore: req.InterestMatchScore,
+		SuccessComponent:   nullFloat(req.SuccessComponent),
+		InterestComponent:  nullFloat(req.InterestComponent),
+		DistanceComponent:  nullFloat(req.DistanceComponent),
+		WaitComponent:      nullFloat(req.WaitComponent),
+		TierComponent:      nullFloat(req.TierComponent),
+	}
+	if req.PolicyVersion.Valid {
+		version := int(req.PolicyVersion.Int64)
+		response.PolicyVersion = &version
+	}
+	if req.ScoredAt.Valid {
+		scoredAt := req.ScoredAt.Time.Format(time.RFC3339)
+		response.ScoredAt = &scoredAt
+	}
+
+	// The distance is to the book's holder, so it is only shown as
+	// precisely as their location privacy allows
+	if req.DistanceKm.Valid {
+		book, err := h.bookRepo.FindByID(req.BookID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch book"))
+			return
+		}
+		holderID := book.CurrentHolderID
+		if !holderID.Valid {
+			holderID = book.CreatedBy
+		}
+		if holder, err := h.userRepo.FindByID(holderID.String); err == nil {
+			response.DistanceKm = services.PublicDistanceKm(req.DistanceKm.Float64, holder.LocationPrivacy)
+		}
+	}
+
+	if req.Status == "pending" {
+		ranked, err := h.matchingService.RankPending(req.BookID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.Error("Failed to rank request"))
+			return
+		}
+		response.Competing = len(ranked)
+		for i, cand := range ranked {
+			if cand.RequestID == req.ID {
+				response.Rank = i + 1
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Request score retrieved successfully", response))
+}
+
+// GetBookRequests lists who is waiting for a book, with each requester's
+// trust summary, for the member deciding who receives it next.
+func (h *MatchingHandler) GetBookRequests(c *gin.Context) {
+	book, err := h.bookRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Book not found"))
+		return
+	}
+
+	owner := book.CurrentHolderID
+	if !owner.Valid {
+		owner = book.CreatedBy
+	}
+	if owner.String != c.GetString("user_id") && c.GetString("user_role") != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, dto.Error("Only the current holder can view requests for this book"))
+		return
+	}
+
+	requests, err := h.requestRepo.ListPending(book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch requests"))
+		return
+	}
+
+	// List them in allocation order, win cap and tie-break included
+	ranked, err := h.matchingService.RankPending(book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to rank requests"))
+		return
+	}
+	// Requesters who can't receive the book right now go last
+	position := make(map[string]int, len(ranked))
+	for i, cand := range ranked {
+		position[cand.RequestID] = i
+	}
+	rank := func(id string) int {
+		if i, ok := position[id]; ok {
+			return i
+		}
+		return len(ranked)
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		return rank(requests[i].ID) < rank(requests[j].ID)
+	})
+
+	userIDs := make([]string, len(requests))
+	for i, req := range requests {
+		userIDs[i] = req.UserID
+	}
+	trust, err := h.trustService.Summaries(userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to load requester reviews"))
+		return
+	}
+
+	response := make([]dto.PendingRequestResponse, len(requests))
+	for i, req := range requests {
+		response[i] = dto.PendingRequestResponse{
+			RequestID:     req.ID,
+			Rank:          i + 1,
+			UserID:        req.UserID,
+			Username:      req.User.Username,
+			FullName:      req.User.FullName,
+			AvatarURL:     req.User.AvatarURL,
+			SuccessScore:  req.User.SuccessScore,
+			PriorityScore: req.PriorityScore,
+			DistanceKm:    publicDistance(req.DistanceKm, req.User.LocationPrivacy),
+			RequestedAt:   req.RequestedAt.Format(time.RFC3339),
+			Trust:         trust[req.UserID],
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Requests retrieved successfully", response))
+}
+
+func publicDistance(v sql.NullFloat64, privacy models.LocationPrivacy) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return services.PublicDistanceKm(v.Float64, privacy)
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+type RecommendationHandler struct {
+	recommendationService *services.RecommendationService
+}
+
+func NewRecommendationHandler(recommendationService *services.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{recommendationService: recommendationService}
+}
+
+func (h *RecommendationHandler) GetForUser(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 50 {
+		c.JSON(http.StatusBadRequest, dto.Error("limit must be between 1 and 50"))
+		return
+	}
+
+	recs, err := h.recommendationService.Recommend(c.GetString("user_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to build recommendations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Recommendations retrieved successfully", recs))
+}
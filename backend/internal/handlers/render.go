@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/yourusername/online-library/internal/markdown"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+// User-written text is stored as Markdown source and rendered on the way
+// out, so a change to the sanitizer policy applies to existing content too.
+
+func renderIdeas(ideas ...*models.ReadingIdea) {
+	for _, idea := range ideas {
+		idea.ContentHTML = markdown.Render(idea.Content)
+	}
+}
+
+func renderComments(comments []*models.IdeaComment) {
+	for _, comment := range comments {
+		comment.ContentHTML = markdown.Render(comment.Content)
+		renderComments(comment.Replies)
+	}
+}
+
+func renderReviews(reviews ...*models.UserReview) {
+	for _, review := range reviews {
+		if review != nil {
+			review.CommentHTML = markdown.Render(review.Comment)
+		}
+	}
+}
+
+func renderDonations(donations ...*models.Donation) {
+	for _, donation := range donations {
+		donation.MessageHTML = markdown.Render(donation.Message)
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+// Content with this many open reports is hidden until a moderator decides
+const reportHideThreshold = 3
+
+type ReportHandler struct {
+	reportRepo   *repository.ReportRepository
+	ideaRepo     *repository.IdeaRepository
+	commentRepo  *repository.CommentRepository
+	reviewRepo   *repository.ReviewRepository
+	scoreService *services.SuccessScoreService
+	notifService *services.NotificationService
+	auditService *services.AuditService
+}
+
+func NewReportHandler(reportRepo *repository.ReportRepository, ideaRepo *repository.IdeaRepository, commentRepo *repository.CommentRepository, reviewRepo *repository.ReviewRepository, scoreService *services.SuccessScoreService, notifService *services.NotificationService, auditService *services.AuditService) *ReportHandler {
+	return &ReportHandler{
+		reportRepo:   reportRepo,
+		ideaRepo:     ideaRepo,
+		commentRepo:  commentRepo,
+		reviewRepo:   reviewRepo,
+		scoreService: scoreService,
+		notifService: notifService,
+		auditService: auditService,
+	}
+}
+
+// Create files a report against a piece of content. Each member can report
+// the same content once; enough open reports hide it automatically.
+func (h *ReportHandler) Create(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req dto.CreateReportRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resourceType := models.ReportResourceType(req.ResourceType)
+	content, err := h.reportRepo.FindContent(resourceType, req.ResourceID)
+	if err != nil || content.Deleted {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Content not found"})
+		return
+	}
+	if content.AuthorID.String == userID {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "You cannot report your own content"})
+		return
+	}
+
+	report := &models.ContentReport{
+		ResourceType: resourceType,
+		ResourceID:   req.ResourceID,
+		AuthorID:     content.AuthorID,
+		ReporterID:   userID,
+		Reason:       req.Reason,
+		Details:      req.Details,
+	}
+	if err := h.reportRepo.Create(report); err != nil {
+		if err == repository.ErrAlreadyReported {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to file report"})
+		return
+	}
+
+	if open, err := h.reportRepo.CountOpen(resourceType, req.ResourceID); err == nil && open >= reportHideThreshold {
+		if hidden, err := h.reportRepo.AutoHide(resourceType, req.ResourceID); err == nil && hidden {
+			if content.AuthorID.Valid {
+				h.notifService.NotifyContentHidden(content.AuthorID.String, req.ResourceType)
+			}
+			h.audit(c, "content.auto_hidden", content, map[string]interface{}{"open_reports": open})
+		}
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetQueue lists reported content for moderators.
+func (h *ReportHandler) GetQueue(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	queue, err := h.reportRepo.Queue(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch reports"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Reports retrieved successfully", queue))
+}
+
+// GetReports shows a piece of content with every report filed against it.
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	content, err := h.reportRepo.FindContent(models.ReportResourceType(c.Param("type")), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Content not found"))
+		return
+	}
+
+	reports, err := h.reportRepo.ListForContent(content.ResourceType, content.ResourceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch reports"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Reports retrieved successfully", gin.H{
+		"content": content,
+		"reports": reports,
+	}))
+}
+
+// Resolve applies a moderator's decision to reported content and closes
+// its open reports. Reporters hear the outcome; the author hears about any
+// action taken against them.
+func (h *ReportHandler) Resolve(c *gin.Context) {
+	var req dto.ResolveReportsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	content, err := h.reportRepo.FindContent(models.ReportResourceType(c.Param("type")), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Content not found"))
+		return
+	}
+	if open, err := h.reportRepo.CountOpen(content.ResourceType, content.ResourceID); err != nil || open == 0 {
+		c.JSON(http.StatusConflict, dto.Error(repository.ErrNoOpenReports.Error()))
+		return
+	}
+
+	action := models.ModerationAction(req.Action)
+	details := map[string]interface{}{"action": req.Action, "note": req.Note}
+	var remove func(tx *sql.Tx) error
+	change := 0
+	if action == models.ActionDelete && !content.Deleted {
+		remove, err = h.deleteContent(content, c.GetString("user_id"), &change)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.Error("Failed to delete content"))
+			return
+		}
+	}
+
+	reporters, err := h.reportRepo.Resolve(content, action, c.GetString("user_id"), req.Note, remove)
+	if err != nil {
+		if err == repository.ErrNoOpenReports {
+			c.JSON(http.StatusConflict, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to resolve reports"))
+		return
+	}
+	details["reports"] = len(reporters)
+	if change != 0 {
+		details["score_change"] = change
+	}
+
+	for _, reporterID := range reporters {
+		h.notifService.NotifyReportResolved(reporterID, string(content.ResourceType), action != models.ActionDismiss)
+	}
+	if action != models.ActionDismiss && content.AuthorID.Valid {
+		h.notifService.NotifyContentModerated(content.AuthorID.String, string(content.ResourceType), req.Action)
+	}
+	h.audit(c, "content."+req.Action, content, details)
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Reports resolved", gin.H{
+		"resource_type": content.ResourceType,
+		"resource_id":   content.ResourceID,
+		"action":        action,
+		"reports":       len(reporters),
+	}))
+}
+
+// deleteContent prepares the removal of reported content the way its
+// owner's own delete would, for Resolve to run in its transaction. Any
+// success score change it causes is stored in change.
+func (h *ReportHandler) deleteContent(content *models.ReportedContent, moderatorID string, change *int) (func(tx *sql.Tx) error, error) {
+	switch content.ResourceType {
+	case models.ReportIdea:
+		idea, err := h.ideaRepo.GetByID(content.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		return func(tx *sql.Tx) error {
+			if err := h.ideaRepo.DeleteTx(tx, idea, moderatorID); err != nil {
+				return err
+			}
+			return h.scoreService.ReverseIdeaPostedTx(tx, idea.UserID, idea.ID)
+		}, nil
+	case models.ReportComment:
+		comment, err := h.commentRepo.FindByID(content.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		return func(tx *sql.Tx) error {
+			return h.commentRepo.SoftDeleteTx(tx, comment)
+		}, nil
+	case models.ReportReview:
+		review, err := h.reviewRepo.FindByID(content.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		return func(tx *sql.Tx) error {
+			if err := h.reviewRepo.RemoveTx(tx, review); err != nil {
+				return err
+			}
+			total, err := h.scoreService.ReverseReviewTx(tx, review.RevieweeID, review.ID, "Review removed by a moderator")
+			*change = total
+			return err
+		}, nil
+	default:
+		return func(tx *sql.Tx) error {
+			return h.reportRepo.ClearTextTx(tx, content.ResourceType, content.ResourceID)
+		}, nil
+	}
+}
+
+func (h *ReportHandler) audit(c *gin.Context, action string, content *models.ReportedContent, details map[string]interface{}) {
+	h.auditService.Log(services.AuditEntry{
+		UserID:       c.GetString("user_id"),
+		Action:       action,
+		ResourceType: string(content.ResourceType),
+		ResourceID:   content.ResourceID,
+		Details:      details,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+}
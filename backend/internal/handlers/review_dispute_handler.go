@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+// How long after a review was posted the reviewee may dispute it
+const disputeWindow = 30 * 24 * time.Hour
+
+type ReviewDisputeHandler struct {
+	disputeRepo  *repository.ReviewDisputeRepository
+	reviewRepo   *repository.ReviewRepository
+	scoreService *services.SuccessScoreService
+	notifService *services.NotificationService
+	auditService *services.AuditService
+}
+
+func NewReviewDisputeHandler(disputeRepo *repository.ReviewDisputeRepository, reviewRepo *repository.ReviewRepository, scoreService *services.SuccessScoreService, notifService *services.NotificationService, auditService *services.AuditService) *ReviewDisputeHandler {
+	return &ReviewDisputeHandler{
+		disputeRepo:  disputeRepo,
+		reviewRepo:   reviewRepo,
+		scoreService: scoreService,
+		notifService: notifService,
+		auditService: auditService,
+	}
+}
+
+// Create lets the reviewee contest a review with a statement for the
+// moderators. Each review can be disputed once.
+func (h *ReviewDisputeHandler) Create(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	review, err := h.reviewRepo.FindByID(c.Param("id"))
+	if err != nil || review.RemovedAt.Valid {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Review not found"})
+		return
+	}
+	if review.RevieweeID != userID {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You can only dispute reviews about you"})
+		return
+	}
+	if time.Since(review.CreatedAt) > disputeWindow {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "This review can no longer be disputed"})
+		return
+	}
+
+	var req dto.CreateReviewDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	dispute := &models.ReviewDispute{
+		ReviewID:   review.ID,
+		DisputedBy: userID,
+		Statement:  req.Statement,
+	}
+	if err := h.disputeRepo.Create(dispute); err != nil {
+		if err == repository.ErrAlreadyDisputed {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to open dispute"})
+		return
+	}
+
+	h.notifService.NotifyReviewDisputed(review.ReviewerID, dispute.ID)
+	h.audit(c, "review_dispute.opened", dispute.ID, map[string]interface{}{
+		"review_id": review.ID,
+	})
+
+	renderReviews(dispute.Review)
+	c.JSON(http.StatusCreated, dispute)
+}
+
+// GetByID shows a dispute to either party of the review, or to an admin.
+func (h *ReviewDisputeHandler) GetByID(c *gin.Context) {
+	dispute, err := h.disputeRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Dispute not found"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID != dispute.Review.ReviewerID && userID != dispute.Review.RevieweeID &&
+		c.GetString("user_role") != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You are not a party to this dispute"})
+		return
+	}
+
+	renderReviews(dispute.Review)
+	c.JSON(http.StatusOK, dispute)
+}
+
+// GetQueue lists disputes for moderators, open ones by default.
+func (h *ReviewDisputeHandler) GetQueue(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	disputes, err := h.disputeRepo.List(c.DefaultQuery("status", string(models.DisputeOpen)), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch disputes"))
+		return
+	}
+
+	for _, d := range disputes {
+		renderReviews(d.Review)
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Disputes retrieved successfully", disputes))
+}
+
+// Resolve upholds or removes a disputed review. Removal hides the review and
+// reverses its effect on the reviewee's success score.
+func (h *ReviewDisputeHandler) Resolve(c *gin.Context) {
+	var req dto.ResolveReviewDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	dispute, err := h.disputeRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Dispute not found"))
+		return
+	}
+
+	status := models.DisputeUpheld
+	if req.Decision == "remove" {
+		status = models.DisputeRemoved
+	}
+
+	var change int
+	err = h.disputeRepo.Resolve(dispute, status, c.GetString("user_id"), req.Note, func(tx *sql.Tx) error {
+		var err error
+		change, err = h.scoreService.ReverseReviewTx(tx, dispute.Review.RevieweeID, dispute.ReviewID, "Review removed after dispute")
+		return err
+	})
+	if err == repository.ErrDisputeResolved {
+		c.JSON(http.StatusConflict, dto.Error(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to resolve dispute"))
+		return
+	}
+
+	details := map[string]interface{}{
+		"review_id": dispute.ReviewID,
+		"decision":  req.Decision,
+		"note":      req.Note,
+	}
+	if status == models.DisputeRemoved {
+		details["score_change"] = change
+	}
+
+	h.notifService.NotifyDisputeResolved(dispute.Review.RevieweeID, dispute.ID, status == models.DisputeRemoved)
+	h.notifService.NotifyDisputeResolved(dispute.Review.ReviewerID, dispute.ID, status == models.DisputeRemoved)
+	h.audit(c, "review_dispute."+string(status), dispute.ID, details)
+
+	renderReviews(dispute.Review)
+	c.JSON(http.StatusOK, dto.SuccessResponse("Dispute resolved", dispute))
+}
+
+func (h *ReviewDisputeHandler) audit(c *gin.Context, action, disputeID string, details map[string]interface{}) {
+	h.auditService.Log(services.AuditEntry{
+		UserID:       c.GetString("user_id"),
+		Action:       action,
+		ResourceType: "review_dispute",
+		ResourceID:   disputeID,
+		Details:      details,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+}
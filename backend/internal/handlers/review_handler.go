@@ -3,28 +3,42 @@ package handlers
 import (
 	"database/sql"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/contentfilter"
 	"github.com/yourusername/online-library/internal/dto"
 	"github.com/yourusername/online-library/internal/models"
 	"github.com/yourusername/online-library/internal/repository"
 	"github.com/yourusername/online-library/internal/services"
 )
 
+const (
+	// How long after a handover either party may review the other
+	reviewWindow = 30 * 24 * time.Hour
+	// How long after posting a review its author may still change it
+	reviewEditWindow = 48 * time.Hour
+)
+
 type ReviewHandler struct {
-	reviewRepo   *repository.ReviewRepository
-	scoreService *services.SuccessScoreService
-	notifService *services.NotificationService
+	reviewRepo    *repository.ReviewRepository
+	scoreService  *services.SuccessScoreService
+	notifService  *services.NotificationService
+	filterService *services.ContentFilterService
 }
 
-func NewReviewHandler(reviewRepo *repository.ReviewRepository, scoreService *services.SuccessScoreService, notifService *services.NotificationService) *ReviewHandler {
+func NewReviewHandler(reviewRepo *repository.ReviewRepository, scoreService *services.SuccessScoreService, notifService *services.NotificationService, filterService *services.ContentFilterService) *ReviewHandler {
 	return &ReviewHandler{
-		reviewRepo:   reviewRepo,
-		scoreService: scoreService,
-		notifService: notifService,
+		reviewRepo:    reviewRepo,
+		scoreService:  scoreService,
+		notifService:  notifService,
+		filterService: filterService,
 	}
 }
 
+// Create records a review of the other party to a completed exchange. Each
+// side may review the other once, within reviewWindow of the handover.
 func (h *ReviewHandler) Create(c *gin.Context) {
 	reviewerID := c.GetString("user_id")
 	var req dto.CreateUserReviewRequest
@@ -34,55 +48,147 @@ func (h *ReviewHandler) Create(c *gin.Context) {
 		return
 	}
 
+	exchange, err := h.reviewRepo.FindExchange(req.ExchangeID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Exchange not found"})
+		return
+	}
+
+	var revieweeID string
+	switch reviewerID {
+	case exchange.ReceiverID:
+		revieweeID = exchange.GiverID
+	case exchange.GiverID:
+		revieweeID = exchange.ReceiverID
+	default:
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You can only review members you exchanged a book with"})
+		return
+	}
+	if revieweeID == "" || revieweeID == reviewerID {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "You cannot review yourself"})
+		return
+	}
+	if time.Since(exchange.HandedOverAt) > reviewWindow {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "The review window for this exchange has closed"})
+		return
+	}
+
 	review := &models.UserReview{
 		ReviewerID: reviewerID,
-		RevieweeID: req.RevieweeID,
+		RevieweeID: revieweeID,
+		BookID:     sql.NullString{String: exchange.BookID, Valid: true},
+		ExchangeID: sql.NullString{String: exchange.ID, Valid: true},
 		Comment:    req.Comment,
 	}
+	review.BehaviorRating = nullRating(req.BehaviorRating)
+	review.BookConditionRating = nullRating(req.BookConditionRating)
+	review.CommunicationRating = nullRating(req.CommunicationRating)
 
-	if req.BookID != "" {
-		review.BookID = sql.NullString{String: req.BookID, Valid: true}
+	// Rating-only reviews have no text to screen
+	post := contentfilter.Post{UserID: reviewerID, Kind: services.PostReview, Text: review.Comment}
+	screened := strings.TrimSpace(review.Comment) != ""
+	var result contentfilter.Result
+	if screened {
+		var ok bool
+		if result, ok = screenPost(c, h.filterService, post); !ok {
+			return
+		}
 	}
-	if req.BehaviorRating != nil {
-		review.BehaviorRating = sql.NullInt64{Int64: int64(*req.BehaviorRating), Valid: true}
+
+	if err := h.reviewRepo.Create(review); err != nil {
+		if err == repository.ErrDuplicateReview {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create review"})
+		return
 	}
-	if req.BookConditionRating != nil {
-		review.BookConditionRating = sql.NullInt64{Int64: int64(*req.BookConditionRating), Valid: true}
+	if screened {
+		h.filterService.Record(post, review.ID, result)
 	}
-	if req.CommunicationRating != nil {
-		review.CommunicationRating = sql.NullInt64{Int64: int64(*req.CommunicationRating), Valid: true}
+
+	switch reviewScoreEvent(review) {
+	case models.EventPositiveReview:
+		h.scoreService.ProcessPositiveReview(revieweeID, review.ID)
+	case models.EventNegativeReview:
+		h.scoreService.ProcessNegativeReview(revieweeID, review.ID)
 	}
 
-	if err := h.reviewRepo.Create(review); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create review"})
+	renderReviews(review)
+	c.JSON(http.StatusCreated, review)
+}
+
+// Update lets the author amend a review shortly after posting it. If the
+// change moves the review across the positive/negative thresholds the
+// reviewee's score is adjusted by the difference.
+func (h *ReviewHandler) Update(c *gin.Context) {
+	review, err := h.reviewRepo.FindByID(c.Param("id"))
+	if err != nil || review.RemovedAt.Valid {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Review not found"})
+		return
+	}
+	if review.ReviewerID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "You can only edit your own reviews"})
+		return
+	}
+	if time.Since(review.CreatedAt) > reviewEditWindow {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "This review can no longer be edited"})
+		return
+	}
+
+	var req dto.UpdateUserReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// Calculate average and update success score
-	avgRating := 0.0
-	count := 0
+	before := reviewScoreEvent(review)
 	if req.BehaviorRating != nil {
-		avgRating += float64(*req.BehaviorRating)
-		count++
+		review.BehaviorRating = nullRating(req.BehaviorRating)
 	}
 	if req.BookConditionRating != nil {
-		avgRating += float64(*req.BookConditionRating)
-		count++
+		review.BookConditionRating = nullRating(req.BookConditionRating)
 	}
 	if req.CommunicationRating != nil {
-		avgRating += float64(*req.CommunicationRating)
-		count++
-	}
-	if count > 0 {
-		avgRating /= float64(count)
-		if avgRating >= 4.0 {
-			h.scoreService.ProcessPositiveReview(req.RevieweeID, review.ID)
-		} else if avgRating < 3.0 {
-			h.scoreService.ProcessNegativeReview(req.RevieweeID, review.ID)
+		review.CommunicationRating = nullRating(req.CommunicationRating)
+	}
+	post := contentfilter.Post{UserID: review.ReviewerID, Kind: services.PostReview, ResourceID: review.ID}
+	screened := false
+	if req.Comment != nil && *req.Comment != review.Comment {
+		review.Comment = *req.Comment
+		post.Text = review.Comment
+		screened = strings.TrimSpace(review.Comment) != ""
+	}
+	var result contentfilter.Result
+	if screened {
+		var ok bool
+		if result, ok = screenPost(c, h.filterService, post); !ok {
+			return
 		}
 	}
 
-	c.JSON(http.StatusCreated, review)
+	if err := h.reviewRepo.Update(review); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update review"})
+		return
+	}
+	if screened {
+		h.filterService.Record(post, review.ID, result)
+	}
+
+	h.scoreService.ProcessReviewUpdate(review.RevieweeID, review.ID, before, reviewScoreEvent(review))
+
+	renderReviews(review)
+	c.JSON(http.StatusOK, review)
+}
+
+// GetPending lists the caller's recent exchanges that they can still review.
+func (h *ReviewHandler) GetPending(c *gin.Context) {
+	exchanges, err := h.reviewRepo.ListReviewable(c.GetString("user_id"), time.Now().Add(-reviewWindow))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch exchanges"})
+		return
+	}
+	c.JSON(http.StatusOK, exchanges)
 }
 
 func (h *ReviewHandler) GetByUser(c *gin.Context) {
@@ -92,5 +198,36 @@ func (h *ReviewHandler) GetByUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch reviews"})
 		return
 	}
+	renderReviews(reviews...)
 	c.JSON(http.StatusOK, reviews)
 }
+
+// reviewScoreEvent is the success score effect of a review: positive when
+// the average rating is 4 or more, negative below 3, otherwise none.
+func reviewScoreEvent(review *models.UserReview) models.ScoreEvent {
+	total, count := 0.0, 0
+	for _, r := range []sql.NullInt64{review.BehaviorRating, review.BookConditionRating, review.CommunicationRating} {
+		if r.Valid {
+			total += float64(r.Int64)
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+
+	switch avg := total / float64(count); {
+	case avg >= 4.0:
+		return models.EventPositiveReview
+	case avg < 3.0:
+		return models.EventNegativeReview
+	}
+	return ""
+}
+
+func nullRating(rating *int) sql.NullInt64 {
+	if rating == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*rating), Valid: true}
+}
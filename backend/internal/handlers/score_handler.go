@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+type ScoreHandler struct {
+	ruleRepo     *repository.ScoreRuleRepository
+	scoreService *services.SuccessScoreService
+}
+
+func NewScoreHandler(ruleRepo *repository.ScoreRuleRepository, scoreService *services.SuccessScoreService) *ScoreHandler {
+	return &ScoreHandler{
+		ruleRepo:     ruleRepo,
+		scoreService: scoreService,
+	}
+}
+
+func (h *ScoreHandler) GetActiveRules(c *gin.Context) {
+	rules, err := h.ruleRepo.GetActive()
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Active score rules", rules))
+}
+
+func (h *ScoreHandler) GetRuleSets(c *gin.Context) {
+	sets, err := h.ruleRepo.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to fetch score rules"))
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse("Score rules retrieved successfully", sets))
+}
+
+// CreateRuleSet stores a new rule set version and activates it. It applies
+// to new events only; existing scores change when they are recomputed.
+func (h *ScoreHandler) CreateRuleSet(c *gin.Context) {
+	var req dto.CreateScoreRuleSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	rules := &models.ScoreRuleSet{
+		StartingScore:   req.StartingScore,
+		MinRequestScore: req.MinRequestScore,
+		Notes:           req.Notes,
+		CreatedBy:       sql.NullString{String: c.GetString("user_id"), Valid: true},
+	}
+	seen := make(map[string]bool)
+	for _, r := range req.Rules {
+		switch {
+		case !models.IsScoreEvent(r.EventType):
+			c.JSON(http.StatusBadRequest, dto.Error(fmt.Sprintf("unknown event type: %s", r.EventType)))
+			return
+		case seen[r.EventType]:
+			c.JSON(http.StatusBadRequest, dto.Error(fmt.Sprintf("duplicate rule for %s", r.EventType)))
+			return
+		case r.PeriodCap > 0 && r.CapPeriodDays == 0:
+			c.JSON(http.StatusBadRequest, dto.Error(fmt.Sprintf("cap_period_days is required when %s has a period_cap", r.EventType)))
+			return
+		}
+		seen[r.EventType] = true
+		rules.Rules = append(rules.Rules, models.ScoreRule{
+			EventType:     models.ScoreEvent(r.EventType),
+			Delta:         r.Delta,
+			PeriodCap:     r.PeriodCap,
+			CapPeriodDays: r.CapPeriodDays,
+		})
+	}
+
+	levels := make(map[int]bool)
+	for _, t := range req.Tiers {
+		if levels[t.Level] {
+			c.JSON(http.StatusBadRequest, dto.Error(fmt.Sprintf("duplicate tier level %d", t.Level)))
+			return
+		}
+		levels[t.Level] = true
+		rules.Tiers = append(rules.Tiers, models.ScoreTier{
+			Level:             t.Level,
+			Name:              t.Name,
+			MinScore:          t.MinScore,
+			MinCompletedLoans: t.MinCompletedLoans,
+			MaxActiveLoans:    t.MaxActiveLoans,
+			MaxLoanDays:       t.MaxLoanDays,
+			MaxRenewals:       t.MaxRenewals,
+			PriorityBonus:     t.PriorityBonus,
+		})
+	}
+	sort.Slice(rules.Tiers, func(i, j int) bool { return rules.Tiers[i].Level < rules.Tiers[j].Level })
+
+	if err := h.ruleRepo.Create(rules); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to save score rules"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse("Score rules created", rules))
+}
+
+func (h *ScoreHandler) ActivateRuleSet(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error("invalid rule set version"))
+		return
+	}
+
+	if err := h.ruleRepo.Activate(version); err != nil {
+		c.JSON(http.StatusNotFound, dto.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Score rules activated", nil))
+}
+
+// Recompute rebuilds success scores from the score history under the active
+// rules, optionally limited with ?user_id=. With ?dry_run=true it only
+// reports what would change.
+func (h *ScoreHandler) Recompute(c *gin.Context) {
+	rules, err := h.ruleRepo.GetActive()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	changes, err := h.scoreService.Recompute(rules, c.Query("user_id"), !dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to recompute scores"))
+		return
+	}
+
+	message := "Success scores recomputed"
+	if dryRun {
+		message = "Success score recompute preview"
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse(message, gin.H{
+		"rules_version": rules.Version,
+		"dry_run":       dryRun,
+		"changes":       changes,
+	}))
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+type TaxonomyHandler struct {
+	taxonomyRepo *repository.TaxonomyRepository
+}
+
+func NewTaxonomyHandler(taxonomyRepo *repository.TaxonomyRepository) *TaxonomyHandler {
+	return &TaxonomyHandler{taxonomyRepo: taxonomyRepo}
+}
+
+func (h *TaxonomyHandler) GetTerms(c *gin.Context) {
+	terms, err := h.taxonomyRepo.List(c.Query("kind"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Terms retrieved successfully", terms))
+}
+
+func (h *TaxonomyHandler) CreateTerm(c *gin.Context) {
+	var req dto.CreateTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	term := &models.TaxonomyTerm{
+		Kind:    models.TermKind(req.Kind),
+		Name:    req.Name,
+		Aliases: req.Aliases,
+	}
+	if models.NormalizeTerm(term.Name) == "" {
+		c.JSON(http.StatusBadRequest, dto.Error("name must not be blank"))
+		return
+	}
+	if req.ParentID != nil && *req.ParentID != "" {
+		if msg := h.checkParent(term, *req.ParentID); msg != "" {
+			c.JSON(http.StatusBadRequest, dto.Error(msg))
+			return
+		}
+		term.ParentID = sql.NullString{String: *req.ParentID, Valid: true}
+	}
+
+	if err := h.taxonomyRepo.Create(term); err != nil {
+		if err == repository.ErrTermExists || err == repository.ErrAliasTaken {
+			c.JSON(http.StatusConflict, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to create term"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse("Term created successfully", term))
+}
+
+func (h *TaxonomyHandler) UpdateTerm(c *gin.Context) {
+	term, err := h.taxonomyRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Term not found"))
+		return
+	}
+
+	var req dto.UpdateTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	oldName := term.Name
+	if models.NormalizeTerm(req.Name) != "" {
+		term.Name = req.Name
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			term.ParentID = sql.NullString{}
+		} else {
+			if msg := h.checkParent(term, *req.ParentID); msg != "" {
+				c.JSON(http.StatusBadRequest, dto.Error(msg))
+				return
+			}
+			term.ParentID = sql.NullString{String: *req.ParentID, Valid: true}
+		}
+	}
+
+	if err := h.taxonomyRepo.Update(term, oldName); err != nil {
+		if err == repository.ErrTermExists || err == repository.ErrAliasTaken {
+			c.JSON(http.StatusConflict, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to update term"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Term updated successfully", term))
+}
+
+func (h *TaxonomyHandler) DeleteTerm(c *gin.Context) {
+	if err := h.taxonomyRepo.Delete(c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Term deleted successfully", nil))
+}
+
+func (h *TaxonomyHandler) AddAlias(c *gin.Context) {
+	term, err := h.taxonomyRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Term not found"))
+		return
+	}
+
+	var req dto.AddAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	if err := h.taxonomyRepo.AddAlias(term.ID, term.Kind, req.Alias); err != nil {
+		if err == repository.ErrAliasTaken {
+			c.JSON(http.StatusConflict, dto.Error(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Error("Failed to add alias"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Alias added successfully", nil))
+}
+
+func (h *TaxonomyHandler) DeleteAlias(c *gin.Context) {
+	if err := h.taxonomyRepo.DeleteAlias(c.Param("id"), c.Param("alias")); err != nil {
+		c.JSON(http.StatusNotFound, dto.Error(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse("Alias removed successfully", nil))
+}
+
+// MergeTerm folds a duplicate term into another one and rewrites every book
+// and interest that used it.
+func (h *TaxonomyHandler) MergeTerm(c *gin.Context) {
+	var req dto.MergeTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+	if req.IntoID == c.Param("id") {
+		c.JSON(http.StatusBadRequest, dto.Error("Cannot merge a term into itself"))
+		return
+	}
+
+	source, err := h.taxonomyRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Term not found"))
+		return
+	}
+	target, err := h.taxonomyRepo.FindByID(req.IntoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Error("Target term not found"))
+		return
+	}
+
+	if err := h.taxonomyRepo.Merge(source, target); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error(err.Error()))
+		return
+	}
+
+	target, _ = h.taxonomyRepo.FindByID(target.ID)
+	c.JSON(http.StatusOK, dto.SuccessResponse("Terms merged successfully", target))
+}
+
+// checkParent returns a message when parentID can't be term's parent: it
+// must exist, be the same kind, and not sit below term in the hierarchy.
+func (h *TaxonomyHandler) checkParent(term *models.TaxonomyTerm, parentID string) string {
+	parent, err := h.taxonomyRepo.FindByID(parentID)
+	if err != nil {
+		return "Parent term not found"
+	}
+	if parent.Kind != term.Kind {
+		return "Parent term must be the same kind"
+	}
+	for p := parent; ; {
+		if term.ID != "" && p.ID == term.ID {
+			return "A term cannot be nested under itself"
+		}
+		if !p.ParentID.Valid {
+			return ""
+		}
+		if p, err = h.taxonomyRepo.FindByID(p.ParentID.String); err != nil {
+			return ""
+		}
+	}
+}
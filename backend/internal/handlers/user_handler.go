@@ -3,36 +3,87 @@ package handlers
 import (
 	"database/sql"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/online-library/internal/contentfilter"
 	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/markdown"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/services"
 )
 
 type UserHandler struct {
-	db *sql.DB
+	db              *sql.DB
+	locationService *services.LocationService
+	matchingService *services.MatchingService
+	taxonomyService *services.TaxonomyService
+	trustService    *services.TrustService
+	filterService   *services.ContentFilterService
+	lendingPolicy   *services.LendingPolicyService
+	achievements    *services.AchievementService
 }
 
-func NewUserHandler(db *sql.DB) *UserHandler {
-	return &UserHandler{db: db}
+func NewUserHandler(db *sql.DB, locationService *services.LocationService, matchingService *services.MatchingService, taxonomyService *services.TaxonomyService, trustService *services.TrustService, filterService *services.ContentFilterService, lendingPolicy *services.LendingPolicyService, achievements *services.AchievementService) *UserHandler {
+	return &UserHandler{
+		db:              db,
+		locationService: locationService,
+		matchingService: matchingService,
+		taxonomyService: taxonomyService,
+		trustService:    trustService,
+		filterService:   filterService,
+		lendingPolicy:   lendingPolicy,
+		achievements:    achievements,
+	}
 }
 
 func (h *UserHandler) GetPublicProfile(c *gin.Context) {
 	userID := c.Param("id")
 
 	var profile dto.UserPublicProfile
+	var lat, lng sql.NullFloat64
+	var district string
+	var privacy models.LocationPrivacy
 	err := h.db.QueryRow(`
-		SELECT id, username, full_name, avatar_url, bio, success_score, 
-			books_shared, books_received, reviews_received, ideas_posted, is_donor, created_at
+		SELECT id, username, full_name, avatar_url,
+			CASE WHEN bio_hidden_at IS NULL THEN bio ELSE '' END, success_score,
+			books_shared, books_received, reviews_received, ideas_posted, is_donor, created_at,
+			location_lat, location_lng, COALESCE(location_district, ''), COALESCE(location_privacy, 'district')
 		FROM users WHERE id = $1
 	`, userID).Scan(&profile.ID, &profile.Username, &profile.FullName, &profile.AvatarURL,
 		&profile.Bio, &profile.SuccessScore, &profile.BooksShared, &profile.BooksReceived,
-		&profile.ReviewsReceived, &profile.IdeasPosted, &profile.IsDonor, &profile.JoinedAt)
+		&profile.ReviewsReceived, &profile.IdeasPosted, &profile.IsDonor, &profile.JoinedAt,
+		&lat, &lng, &district, &privacy)
 
 	if err != nil {
 		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "User not found"})
 		return
 	}
 
+	profile.BioHTML = markdown.Render(profile.Bio)
+
+	isOwner := profile.ID == c.GetString("user_id")
+	profile.Location = h.locationService.PublicLocation(lat, lng, district, privacy, isOwner)
+
+	profile.Trust, err = h.trustService.Summary(profile.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load reviews"})
+		return
+	}
+
+	standing, err := h.lendingPolicy.Standing(profile.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load tier"})
+		return
+	}
+	profile.Tier = services.TierSummary(standing)
+
+	profile.Badges, err = h.achievements.Badges(profile.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to load badges"})
+		return
+	}
+
 	c.JSON(http.StatusOK, profile)
 }
 
@@ -45,19 +96,46 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	_, err := h.db.Exec(`
+	location, err := h.locationService.Resolve(req.LocationAddress, req.District, req.LocationLat, req.LocationLng)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Only a changed bio goes through the content filter
+	var currentBio string
+	if err := h.db.QueryRow(`SELECT COALESCE(bio, '') FROM users WHERE id = $1`, userID).Scan(&currentBio); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update profile"})
+		return
+	}
+	bioPost := contentfilter.Post{UserID: userID, Kind: services.PostBio, ResourceID: userID, Text: req.Bio}
+	bioChanged := req.Bio != currentBio && strings.TrimSpace(req.Bio) != ""
+	var bioResult contentfilter.Result
+	if bioChanged {
+		var ok bool
+		if bioResult, ok = screenPost(c, h.filterService, bioPost); !ok {
+			return
+		}
+	}
+
+	_, err = h.db.Exec(`
 		UPDATE users 
 		SET full_name = $1, bio = $2, avatar_url = $3, 
 		    location_lat = $4, location_lng = $5, location_address = $6,
+		    location_district = NULLIF($7, ''),
+		    location_privacy = COALESCE(NULLIF($8, ''), location_privacy),
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $7
-	`, req.FullName, req.Bio, req.AvatarURL, req.LocationLat, req.LocationLng,
-		req.LocationAddress, userID)
+		WHERE id = $9
+	`, req.FullName, req.Bio, req.AvatarURL, location.Lat, location.Lng,
+		location.Address, location.District, req.LocationPrivacy, userID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update profile"})
 		return
 	}
+	if bioChanged {
+		h.filterService.Record(bioPost, userID, bioResult)
+	}
 
 	c.JSON(http.StatusOK, dto.SuccessResponse("Profile updated successfully", nil))
 }
@@ -71,6 +149,12 @@ func (h *UserHandler) AddInterests(c *gin.Context) {
 		return
 	}
 
+	interests, err := h.taxonomyService.NormalizeInterests(req.Interests)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to add interests"})
+		return
+	}
+
 	tx, err := h.db.Begin()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Database error"})
@@ -78,11 +162,13 @@ func (h *UserHandler) AddInterests(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	for _, interest := range req.Interests {
+	for _, interest := range interests {
 		_, err := tx.Exec(`
-			INSERT INTO user_interests (user_id, interest)
-			VALUES ($1, $2)
-			ON CONFLICT (user_id, interest) DO NOTHING
+			INSERT INTO user_interests (user_id, interest, explicit_weight)
+			VALUES ($1, $2, 1.0)
+			ON CONFLICT (user_id, interest) DO UPDATE
+			SET explicit_weight = COALESCE(user_interests.explicit_weight, 1.0),
+			    weight = GREATEST(user_interests.weight, 1.0)
 		`, userID, interest)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to add interests"})
@@ -95,6 +181,12 @@ func (h *UserHandler) AddInterests(c *gin.Context) {
 		return
 	}
 
+	// Interest match feeds the priority of this user's pending requests
+	if _, err := h.matchingService.RecalculatePriorities(services.RescoreScope{UserID: userID}); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update request priorities"})
+		return
+	}
+
 	c.JSON(http.StatusOK, dto.SuccessResponse("Interests added successfully", nil))
 }
 
@@ -129,7 +221,8 @@ func (h *UserHandler) GetLeaderboard(c *gin.Context) {
 
 func (h *UserHandler) getTopUsers(orderBy string, limit int) ([]dto.UserPublicProfile, error) {
 	query := `
-		SELECT id, username, full_name, avatar_url, bio, success_score, 
+		SELECT id, username, full_name, avatar_url,
+			CASE WHEN bio_hidden_at IS NULL THEN bio ELSE '' END, success_score,
 			books_shared, books_received, reviews_received, ideas_posted, is_donor, created_at
 		FROM users
 		ORDER BY ` + orderBy + ` DESC
@@ -151,6 +244,7 @@ func (h *UserHandler) getTopUsers(orderBy string, limit int) ([]dto.UserPublicPr
 		if err != nil {
 			continue
 		}
+		user.BioHTML = markdown.Render(user.Bio)
 		users = append(users, user)
 	}
 	return users, nil
@@ -158,7 +252,8 @@ func (h *UserHandler) getTopUsers(orderBy string, limit int) ([]dto.UserPublicPr
 
 func (h *UserHandler) getTopDonors(limit int) ([]dto.UserPublicProfile, error) {
 	query := `
-		SELECT DISTINCT u.id, u.username, u.full_name, u.avatar_url, u.bio, u.success_score, 
+		SELECT DISTINCT u.id, u.username, u.full_name, u.avatar_url,
+			CASE WHEN u.bio_hidden_at IS NULL THEN u.bio ELSE '' END, u.success_score,
 			u.books_shared, u.books_received, u.reviews_received, u.ideas_posted, u.is_donor, u.created_at
 		FROM users u
 		JOIN donations d ON u.id = d.donor_id
@@ -183,6 +278,7 @@ func (h *UserHandler) getTopDonors(limit int) ([]dto.UserPublicProfile, error) {
 		if err != nil {
 			continue
 		}
+		user.BioHTML = markdown.Render(user.Bio)
 		users = append(users, user)
 	}
 	return users, nil
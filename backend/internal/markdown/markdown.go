@@ -0,0 +1,54 @@
+// Package markdown renders user-written Markdown to HTML that is safe to
+// insert into a page as-is.
+package markdown
+
+import (
+	"bytes"
+	stdhtml "html"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// Raw HTML in the source is dropped by goldmark (it is not rendered with
+// WithUnsafe); the sanitizer then only lets through the tags Markdown itself
+// produces, so nothing a user types can add scripts, styles, event handlers
+// or javascript: links.
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(extension.Strikethrough, extension.Linkify),
+		goldmark.WithRendererOptions(html.WithHardWraps()),
+	)
+	policy = newPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "hr", "strong", "em", "del", "code", "pre", "blockquote",
+		"ul", "ol", "li", "h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.RequireNoReferrerOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Render converts Markdown source to sanitized HTML. Empty input renders
+// to an empty string.
+func Render(src string) string {
+	if src == "" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		// goldmark only fails on writer errors, which a buffer doesn't have;
+		// fall back to the escaped source rather than dropping the text.
+		return "<p>" + stdhtml.EscapeString(src) + "</p>"
+	}
+	return policy.Sanitize(buf.String())
+}
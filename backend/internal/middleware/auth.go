@@ -49,3 +49,16 @@ func AdminOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// ModeratorOnly lets admins and moderators through.
+func ModeratorOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("user_role")
+		if !exists || (role != "admin" && role != "moderator") {
+			c.JSON(http.StatusForbidden, dto.Error("moderator access required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
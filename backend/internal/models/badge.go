@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// BadgeMetric is a running total of something a member has done that
+// badges can be awarded on.
+type BadgeMetric string
+
+const (
+	MetricBooksShared     BadgeMetric = "books_shared"
+	MetricOnTimeReturns   BadgeMetric = "on_time_returns"
+	MetricUpvotesReceived BadgeMetric = "upvotes_received"
+	MetricDonations       BadgeMetric = "donations"
+	MetricKmTravelled     BadgeMetric = "km_travelled" // distance the books a member listed have travelled
+)
+
+var BadgeMetrics = []BadgeMetric{
+	MetricBooksShared, MetricOnTimeReturns, MetricUpvotesReceived, MetricDonations, MetricKmTravelled,
+}
+
+func IsBadgeMetric(metric string) bool {
+	for _, m := range BadgeMetrics {
+		if string(m) == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// Badge is a milestone, earned once a member's total for Metric reaches
+// Threshold. Inactive badges are no longer awarded but stay on profiles.
+type Badge struct {
+	ID          string         `json:"id"`
+	Code        string         `json:"code"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Icon        sql.NullString `json:"icon"`
+	Metric      BadgeMetric    `json:"metric"`
+	Threshold   int            `json:"threshold"`
+	IsActive    bool           `json:"is_active"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// UserBadge is a badge a member has earned.
+type UserBadge struct {
+	UserID    string    `json:"user_id"`
+	Badge     Badge     `json:"badge"`
+	AwardedAt time.Time `json:"awarded_at"`
+}
@@ -29,6 +29,47 @@ type Book struct {
 	UpdatedAt       time.Time      `json:"updated_at"`
 }
 
+// NearbyBook is a book annotated with its distance from a search origin.
+// The holder's stored location is only used to work out the public distance
+// and is never serialized.
+type NearbyBook struct {
+	Book
+	DistanceKm float64 `json:"distance_km"`
+
+	HolderPrivacy LocationPrivacy `json:"-"`
+}
+
+// SimilarBook is a book related to another, with the precomputed parts of
+// its similarity score.
+type SimilarBook struct {
+	Book
+	Similarity    float64 `json:"similarity"`
+	TopicScore    float64 `json:"topic_score"`
+	TagScore      float64 `json:"tag_score"`
+	SameAuthor    bool    `json:"same_author"`
+	SameCategory  bool    `json:"same_category"`
+	CoreaderScore float64 `json:"coreader_score"`
+}
+
+// BookSimilarity is one precomputed row of book_similarities.
+type BookSimilarity struct {
+	BookID        string
+	SimilarBookID string
+	Score         float64
+	TopicScore    float64
+	TagScore      float64
+	SameAuthor    bool
+	SameCategory  bool
+	CoreaderScore float64
+}
+
+// Recommendation is a book suggested to a member, with a short explanation.
+type Recommendation struct {
+	Book   *Book   `json:"book"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
 type BookStatus string
 
 const (
@@ -75,23 +116,87 @@ type BookRequest struct {
 	PriorityScore      float64         `json:"priority_score"`
 	InterestMatchScore float64         `json:"interest_match_score"`
 	DistanceKm         sql.NullFloat64 `json:"distance_km"`
+	SuccessComponent   sql.NullFloat64 `json:"success_component"`
+	InterestComponent  sql.NullFloat64 `json:"interest_component"`
+	DistanceComponent  sql.NullFloat64 `json:"distance_component"`
+	WaitComponent      sql.NullFloat64 `json:"wait_component"`
+	TierComponent      sql.NullFloat64 `json:"tier_component"`
+	PolicyVersion      sql.NullInt64   `json:"policy_version"`
+	ScoredAt           sql.NullTime    `json:"scored_at"`
 	RequestedAt        time.Time       `json:"requested_at"`
 	ProcessedAt        sql.NullTime    `json:"processed_at"`
 	DueDate            sql.NullTime    `json:"due_date"`
+	Renewals           int             `json:"renewals"`
+	ReturnedAt         sql.NullTime    `json:"returned_at"`
 }
 
 type ReadingIdea struct {
-	ID        string    `json:"id"`
-	BookID    string    `json:"book_id"`
-	Book      *Book     `json:"book,omitempty"`
-	UserID    string    `json:"user_id"`
-	User      *User     `json:"user,omitempty"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Upvotes   int       `json:"upvotes"`
-	Downvotes int       `json:"downvotes"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           string        `json:"id"`
+	BookID       string        `json:"book_id"`
+	Book         *Book         `json:"book,omitempty"`
+	UserID       string        `json:"user_id"`
+	User         *User         `json:"user,omitempty"`
+	Title        string        `json:"title"`
+	Content      string        `json:"content"`
+	ContentHTML  string        `json:"content_html"`
+	Chapter      string        `json:"chapter"`
+	PageStart    sql.NullInt64 `json:"page_start"`
+	PageEnd      sql.NullInt64 `json:"page_end"`
+	Quote        string        `json:"quote"`
+	Upvotes      int           `json:"upvotes"`
+	Downvotes    int           `json:"downvotes"`
+	CommentCount int           `json:"comment_count"`
+	EditedAt     sql.NullTime  `json:"edited_at"`
+	HiddenAt     sql.NullTime  `json:"-"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// Highlight is a quote from a book, with the ideas that quote it merged and
+// ranked by their combined net votes. Chapter and pages are the top idea's.
+type Highlight struct {
+	Quote     string        `json:"quote"`
+	Chapter   string        `json:"chapter"`
+	PageStart sql.NullInt64 `json:"page_start"`
+	PageEnd   sql.NullInt64 `json:"page_end"`
+	NetScore  int           `json:"net_score"`
+	IdeaCount int           `json:"idea_count"`
+	IdeaIDs   []string      `json:"idea_ids"`
+}
+
+// IdeaRevision is one saved version of an idea. Diffs are line-based,
+// against the previous revision, and empty for the original post.
+type IdeaRevision struct {
+	ID          string         `json:"id"`
+	IdeaID      string         `json:"idea_id"`
+	Revision    int            `json:"revision"`
+	Title       string         `json:"title"`
+	Content     string         `json:"content"`
+	TitleDiff   string         `json:"title_diff"`
+	ContentDiff string         `json:"content_diff"`
+	EditedBy    sql.NullString `json:"edited_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// IdeaComment is a comment on an idea or a reply to another comment.
+// Deleted comments stay in the tree as placeholders so replies keep their
+// context, with content and author cleared.
+type IdeaComment struct {
+	ID          string         `json:"id"`
+	IdeaID      string         `json:"idea_id"`
+	UserID      string         `json:"user_id,omitempty"`
+	Username    string         `json:"username,omitempty"`
+	ParentID    sql.NullString `json:"parent_id"`
+	Depth       int            `json:"depth"`
+	Content     string         `json:"content"`
+	ContentHTML string         `json:"content_html"`
+	Upvotes     int            `json:"upvotes"`
+	Downvotes   int            `json:"downvotes"`
+	IsDeleted   bool           `json:"is_deleted"`
+	IsHidden    bool           `json:"is_hidden"`
+	EditedAt    sql.NullTime   `json:"edited_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Replies     []*IdeaComment `json:"replies"`
 }
 
 type IdeaVote struct {
@@ -102,6 +207,25 @@ type IdeaVote struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+const (
+	VoteUp   = "upvote"
+	VoteDown = "downvote"
+	VoteNone = "none"
+)
+
+// VoteChange is the outcome of setting a member's vote on an idea. Previous
+// and Current are VoteUp, VoteDown or VoteNone.
+type VoteChange struct {
+	IdeaID    string `json:"idea_id"`
+	Previous  string `json:"previous"`
+	Current   string `json:"current"`
+	Upvotes   int    `json:"upvotes"`
+	Downvotes int    `json:"downvotes"`
+	AuthorID  string `json:"-"`
+	IdeaTitle string `json:"-"`
+	VoterName string `json:"-"`
+}
+
 type UserReview struct {
 	ID                  string         `json:"id"`
 	ReviewerID          string         `json:"reviewer_id"`
@@ -113,7 +237,64 @@ type UserReview struct {
 	BookConditionRating sql.NullInt64  `json:"book_condition_rating"`
 	CommunicationRating sql.NullInt64  `json:"communication_rating"`
 	Comment             string         `json:"comment"`
+	CommentHTML         string         `json:"comment_html"`
+	ExchangeID          sql.NullString `json:"exchange_id"`
+	RemovedAt           sql.NullTime   `json:"-"`
 	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+}
+
+type DisputeStatus string
+
+const (
+	DisputeOpen    DisputeStatus = "open"
+	DisputeUpheld  DisputeStatus = "upheld"
+	DisputeRemoved DisputeStatus = "removed"
+)
+
+// ReviewDispute is a reviewee's challenge to a review. An admin either
+// upholds the review or removes it, which also reverses its score effect.
+type ReviewDispute struct {
+	ID             string         `json:"id"`
+	ReviewID       string         `json:"review_id"`
+	Review         *UserReview    `json:"review,omitempty"`
+	DisputedBy     string         `json:"disputed_by"`
+	Statement      string         `json:"statement"`
+	Status         DisputeStatus  `json:"status"`
+	ResolvedBy     sql.NullString `json:"resolved_by"`
+	ResolutionNote string         `json:"resolution_note"`
+	CreatedAt      time.Time      `json:"created_at"`
+	ResolvedAt     sql.NullTime   `json:"resolved_at"`
+}
+
+// ReviewStats aggregates the reviews a user has received. Averages are
+// null when no review rated that dimension.
+type ReviewStats struct {
+	UserID             string
+	ReviewCount        int
+	BehaviorAvg        sql.NullFloat64
+	BehaviorCount      int
+	ConditionAvg       sql.NullFloat64
+	ConditionCount     int
+	CommunicationAvg   sql.NullFloat64
+	CommunicationCount int
+	RatedCount         int
+	RatingSum          float64
+	RecentAvg          sql.NullFloat64
+	RecentCount        int
+	EarlierAvg         sql.NullFloat64
+	EarlierCount       int
+}
+
+// Exchange is a completed handover of a book: the receiver's reading_history
+// entry, and whoever held the book before them.
+type Exchange struct {
+	ID           string    `json:"id"`
+	BookID       string    `json:"book_id"`
+	BookTitle    string    `json:"book_title"`
+	GiverID      string    `json:"giver_id"`
+	ReceiverID   string    `json:"receiver_id"`
+	HandedOverAt time.Time `json:"handed_over_at"`
 }
 
 type Donation struct {
@@ -126,16 +307,20 @@ type Donation struct {
 	Amount       sql.NullFloat64 `json:"amount"`
 	Currency     string          `json:"currency"`
 	Message      string          `json:"message"`
+	MessageHTML  string          `json:"message_html"`
 	IsPublic     bool            `json:"is_public"`
 	CreatedAt    time.Time       `json:"created_at"`
 }
 
 type UserInterest struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Interest  string    `json:"interest"`
-	Weight    float64   `json:"weight"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             string          `json:"id"`
+	UserID         string          `json:"user_id"`
+	Interest       string          `json:"interest"`
+	Weight         float64         `json:"weight"`
+	ExplicitWeight sql.NullFloat64 `json:"explicit_weight"`
+	LastSignalAt   sql.NullTime    `json:"last_signal_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
 }
 
 type UserBookmark struct {
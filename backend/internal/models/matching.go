@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type DistanceDecay string
+
+const (
+	DecayLinear      DistanceDecay = "linear"
+	DecayExponential DistanceDecay = "exponential"
+)
+
+// TieBreak decides between requests with equal priority scores.
+type TieBreak string
+
+const (
+	TieBreakOldestRequest      TieBreak = "oldest_request"
+	TieBreakFewestRecentWins   TieBreak = "fewest_recent_wins"
+	TieBreakLowestSuccessScore TieBreak = "lowest_success_score"
+)
+
+// MatchingPolicy holds the admin-tunable parameters used to rank competing
+// book requests. Policies are append-only; each change creates a new version.
+type MatchingPolicy struct {
+	ID                 string         `json:"id"`
+	Version            int            `json:"version"`
+	SuccessWeight      float64        `json:"success_weight"`
+	InterestWeight     float64        `json:"interest_weight"`
+	DistanceWeight     float64        `json:"distance_weight"`
+	WaitWeight         float64        `json:"wait_weight"`
+	DistanceDecay      DistanceDecay  `json:"distance_decay"`
+	DistanceScaleKm    float64        `json:"distance_scale_km"`
+	MissingDistanceKm  float64        `json:"missing_distance_km"`
+	WaitSaturationDays int            `json:"wait_saturation_days"`
+	AgingPointsPerDay  float64        `json:"aging_points_per_day"`
+	MaxWinsPerPeriod   int            `json:"max_wins_per_period"` // 0 = no cap
+	WinPeriodDays      int            `json:"win_period_days"`
+	TieBreak           TieBreak       `json:"tie_break"`
+	Notes              string         `json:"notes"`
+	IsActive           bool           `json:"is_active"`
+	CreatedBy          sql.NullString `json:"created_by"`
+	CreatedAt          time.Time      `json:"created_at"`
+}
+
+// ScoreBreakdown explains how a request's priority score was put together.
+// Each component is already weighted, so they sum to PriorityScore.
+type ScoreBreakdown struct {
+	PolicyVersion      int      `json:"policy_version"`
+	PriorityScore      float64  `json:"priority_score"`
+	SuccessComponent   float64  `json:"success_component"`
+	InterestComponent  float64  `json:"interest_component"`
+	DistanceComponent  float64  `json:"distance_component"`
+	WaitComponent      float64  `json:"wait_component"`
+	TierComponent      float64  `json:"tier_component"`
+	InterestMatchScore float64  `json:"interest_match_score"`
+	DistanceKm         *float64 `json:"distance_km"`
+}
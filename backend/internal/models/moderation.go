@@ -0,0 +1,67 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReportResourceType is the kind of user-written content a report is about.
+type ReportResourceType string
+
+const (
+	ReportIdea     ReportResourceType = "idea"
+	ReportComment  ReportResourceType = "comment"
+	ReportReview   ReportResourceType = "review"
+	ReportBio      ReportResourceType = "bio"
+	ReportDonation ReportResourceType = "donation"
+)
+
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportDismissed ReportStatus = "dismissed"
+	ReportActioned  ReportStatus = "actioned"
+)
+
+// ModerationAction is what a moderator did about reported content. Dismiss
+// closes the reports without action; the others mark them actioned.
+type ModerationAction string
+
+const (
+	ActionDismiss ModerationAction = "dismiss"
+	ActionHide    ModerationAction = "hide"
+	ActionDelete  ModerationAction = "delete"
+	ActionWarn    ModerationAction = "warn"
+)
+
+type ContentReport struct {
+	ID             string             `json:"id"`
+	ResourceType   ReportResourceType `json:"resource_type"`
+	ResourceID     string             `json:"resource_id"`
+	AuthorID       sql.NullString     `json:"author_id"`
+	ReporterID     string             `json:"reporter_id"` // empty when flagged by the content filter
+	Reason         string             `json:"reason"`
+	Details        string             `json:"details"`
+	Status         ReportStatus       `json:"status"`
+	Action         sql.NullString     `json:"action"`
+	ResolvedBy     sql.NullString     `json:"resolved_by"`
+	ResolutionNote string             `json:"resolution_note"`
+	CreatedAt      time.Time          `json:"created_at"`
+	ResolvedAt     sql.NullTime       `json:"resolved_at"`
+}
+
+// ReportedContent is one entry in the moderation queue: a piece of content
+// with its open reports rolled up.
+type ReportedContent struct {
+	ResourceType     ReportResourceType `json:"resource_type"`
+	ResourceID       string             `json:"resource_id"`
+	AuthorID         sql.NullString     `json:"author_id"`
+	Text             string             `json:"text"`
+	Hidden           bool               `json:"hidden"`
+	Deleted          bool               `json:"deleted"`
+	ReportCount      int                `json:"report_count"`
+	Reasons          []string           `json:"reasons"`
+	FirstReportedAt  time.Time          `json:"first_reported_at"`
+	LatestReportedAt time.Time          `json:"latest_reported_at"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ScoreEvent is something a member does that changes their success score.
+// What each event is worth is set by the active ScoreRuleSet.
+type ScoreEvent string
+
+const (
+	EventReturnOnTime   ScoreEvent = "return_on_time"
+	EventReturnLate     ScoreEvent = "return_late"
+	EventPositiveReview ScoreEvent = "positive_review"
+	EventNegativeReview ScoreEvent = "negative_review"
+	EventIdeaPosted     ScoreEvent = "idea_posted"
+	EventIdeaUpvote     ScoreEvent = "idea_upvote"
+	EventIdeaDownvote   ScoreEvent = "idea_downvote"
+	EventLostBook       ScoreEvent = "lost_book"
+	EventBookDonated    ScoreEvent = "book_donated"
+	EventMoneyDonated   ScoreEvent = "money_donated"
+)
+
+var ScoreEvents = []ScoreEvent{
+	EventReturnOnTime, EventReturnLate, EventPositiveReview, EventNegativeReview, EventIdeaPosted,
+	EventIdeaUpvote, EventIdeaDownvote, EventLostBook, EventBookDonated, EventMoneyDonated,
+}
+
+func IsScoreEvent(event string) bool {
+	for _, e := range ScoreEvents {
+		if string(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ScoreRule is what one event is worth. A non-zero PeriodCap limits how
+// many points (or, for penalties, how many lost points) the event may
+// account for within CapPeriodDays.
+type ScoreRule struct {
+	EventType     ScoreEvent `json:"event_type"`
+	Delta         int        `json:"delta"`
+	PeriodCap     int        `json:"period_cap"` // 0 = no cap
+	CapPeriodDays int        `json:"cap_period_days"`
+}
+
+// ScoreRuleSet holds the admin-tunable success score rules. Rule sets are
+// append-only; each change creates a new version.
+type ScoreRuleSet struct {
+	ID              string         `json:"id"`
+	Version         int            `json:"version"`
+	StartingScore   int            `json:"starting_score"`
+	MinRequestScore int            `json:"min_request_score"`
+	Rules           []ScoreRule    `json:"rules"`
+	Tiers           []ScoreTier    `json:"tiers"` // lowest level first
+	Notes           string         `json:"notes"`
+	IsActive        bool           `json:"is_active"`
+	CreatedBy       sql.NullString `json:"created_by"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// Rule returns the rule for an event. Events without one are worth nothing.
+func (rs *ScoreRuleSet) Rule(event ScoreEvent) ScoreRule {
+	for _, r := range rs.Rules {
+		if r.EventType == event {
+			return r
+		}
+	}
+	return ScoreRule{EventType: event}
+}
+
+// ScoreTier is a level of membership earned through success score and
+// completed loans. It sets what a member may borrow and how much their
+// book requests are boosted.
+type ScoreTier struct {
+	Level             int     `json:"level"`
+	Name              string  `json:"name"`
+	MinScore          int     `json:"min_score"`
+	MinCompletedLoans int     `json:"min_completed_loans"`
+	MaxActiveLoans    int     `json:"max_active_loans"`
+	MaxLoanDays       int     `json:"max_loan_days"`
+	MaxRenewals       int     `json:"max_renewals"`
+	PriorityBonus     float64 `json:"priority_bonus"`
+}
+
+// TierFor returns the highest tier whose thresholds a member meets, or the
+// lowest tier when they meet none.
+func (rs *ScoreRuleSet) TierFor(score, completedLoans int) ScoreTier {
+	var tier ScoreTier
+	for i, t := range rs.Tiers {
+		if i == 0 || (score >= t.MinScore && completedLoans >= t.MinCompletedLoans) {
+			tier = t
+		}
+	}
+	return tier
+}
+
+// NextTier returns the tier above the given one, if any.
+func (rs *ScoreRuleSet) NextTier(tier ScoreTier) *ScoreTier {
+	for i, t := range rs.Tiers {
+		if t.Level > tier.Level {
+			return &rs.Tiers[i]
+		}
+	}
+	return nil
+}
+
+// MemberStanding is where a member stands under the active rules: their
+// tier and the lending record it was derived from.
+type MemberStanding struct {
+	Tier           ScoreTier  `json:"tier"`
+	NextTier       *ScoreTier `json:"next_tier,omitempty"`
+	SuccessScore   int        `json:"success_score"`
+	CompletedLoans int        `json:"completed_loans"`
+	ActiveLoans    int        `json:"active_loans"`
+	CanRequest     bool       `json:"can_request"`
+}
+
+// ScoreRecalculation is one member's score before and after rebuilding it
+// from their history under a rule set.
+type ScoreRecalculation struct {
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	CurrentScore   int    `json:"current_score"`
+	RebuiltScore   int    `json:"rebuilt_score"`
+	Difference     int    `json:"difference"`
+	ChangedEntries int    `json:"changed_entries"`
+}
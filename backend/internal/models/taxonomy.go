@@ -0,0 +1,33 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type TermKind string
+
+const (
+	TermCategory TermKind = "category"
+	TermTag      TermKind = "tag"
+	TermTopic    TermKind = "topic"
+)
+
+// TaxonomyTerm is a canonical category, tag or topic. Aliases are other
+// spellings (including Bengali/English variants) that resolve to it.
+type TaxonomyTerm struct {
+	ID        string         `json:"id"`
+	Kind      TermKind       `json:"kind"`
+	Name      string         `json:"name"`
+	ParentID  sql.NullString `json:"parent_id"`
+	Aliases   []string       `json:"aliases"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// NormalizeTerm is the comparison key for terms and aliases: trimmed,
+// whitespace collapsed and lowercased.
+func NormalizeTerm(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -6,32 +6,47 @@ import (
 )
 
 type User struct {
-	ID              string          `json:"id"`
-	Username        string          `json:"username"`
-	Email           string          `json:"email"`
-	PasswordHash    string          `json:"-"`
-	FullName        string          `json:"full_name"`
-	Role            string          `json:"role"`
-	AvatarURL       string          `json:"avatar_url"`
-	Bio             string          `json:"bio"`
-	LocationLat     sql.NullFloat64 `json:"location_lat,omitempty"`
-	LocationLng     sql.NullFloat64 `json:"location_lng,omitempty"`
-	LocationAddress string          `json:"location_address"`
-	SuccessScore    int             `json:"success_score"`
-	BooksShared     int             `json:"books_shared"`
-	BooksReceived   int             `json:"books_received"`
-	ReviewsReceived int             `json:"reviews_received"`
-	IdeasPosted     int             `json:"ideas_posted"`
-	TotalUpvotes    int             `json:"total_upvotes"`
-	TotalDownvotes  int             `json:"total_downvotes"`
-	IsDonor         bool            `json:"is_donor"`
-	CreatedAt       time.Time       `json:"created_at"`
-	UpdatedAt       time.Time       `json:"updated_at"`
+	ID               string          `json:"id"`
+	Username         string          `json:"username"`
+	Email            string          `json:"email"`
+	PasswordHash     string          `json:"-"`
+	FullName         string          `json:"full_name"`
+	Role             string          `json:"role"`
+	AvatarURL        string          `json:"avatar_url"`
+	Bio              string          `json:"bio"`
+	BioHTML          string          `json:"bio_html"`
+	LocationLat      sql.NullFloat64 `json:"-"` // precise; other members only see a fuzzed PublicLocation
+	LocationLng      sql.NullFloat64 `json:"-"`
+	LocationAddress  string          `json:"-"`
+	LocationDistrict string          `json:"location_district"`
+	LocationPrivacy  LocationPrivacy `json:"location_privacy"`
+	SuccessScore     int             `json:"success_score"`
+	BooksShared      int             `json:"books_shared"`
+	BooksReceived    int             `json:"books_received"`
+	ReviewsReceived  int             `json:"reviews_received"`
+	IdeasPosted      int             `json:"ideas_posted"`
+	TotalUpvotes     int             `json:"total_upvotes"`
+	TotalDownvotes   int             `json:"total_downvotes"`
+	IsDonor          bool            `json:"is_donor"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
 }
 
 type UserRole string
 
 const (
-	RoleAdmin  UserRole = "admin"
-	RoleMember UserRole = "member"
+	RoleAdmin     UserRole = "admin"
+	RoleModerator UserRole = "moderator"
+	RoleMember    UserRole = "member"
+)
+
+// LocationPrivacy controls how precisely a member's home location is shown
+// to other members. Distances are always computed from the precise value.
+type LocationPrivacy string
+
+const (
+	PrivacyExact         LocationPrivacy = "exact"
+	PrivacyNeighbourhood LocationPrivacy = "neighbourhood"
+	PrivacyDistrict      LocationPrivacy = "district"
+	PrivacyHidden        LocationPrivacy = "hidden"
 )
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type BadgeRepository struct {
+	db *sql.DB
+}
+
+func NewBadgeRepository(db *sql.DB) *BadgeRepository {
+	return &BadgeRepository{db: db}
+}
+
+var (
+	ErrBadgeNotFound  = fmt.Errorf("badge not found")
+	ErrBadgeCodeTaken = fmt.Errorf("a badge with this code already exists")
+)
+
+// metricTotals computes each metric for every member as (user_id, value).
+var metricTotals = map[models.BadgeMetric]string{
+	models.MetricBooksShared: `
+		SELECT id, books_shared FROM users`,
+	models.MetricOnTimeReturns: `
+		SELECT user_id, COUNT(*) FROM book_requests
+		WHERE status = 'approved' AND returned_at IS NOT NULL
+		  AND (due_date IS NULL OR returned_at <= due_date)
+		GROUP BY user_id`,
+	models.MetricUpvotesReceived: `
+		SELECT id, total_upvotes FROM users`,
+	models.MetricDonations: `
+		SELECT donor_id, COUNT(*) FROM donations GROUP BY donor_id`,
+	// Each handover's distance is the one measured between the requester
+	// and the holder when the request was scored
+	models.MetricKmTravelled: `
+		SELECT b.created_by, SUM(br.distance_km) FROM book_requests br
+		JOIN books b ON b.id = br.book_id
+		WHERE br.status = 'approved' AND br.distance_km IS NOT NULL AND b.created_by IS NOT NULL
+		GROUP BY b.created_by`,
+}
+
+const badgeColumns = `id, code, name, description, icon, metric, threshold, is_active, created_at`
+
+func scanBadge(row interface{ Scan(...interface{}) error }, b *models.Badge) error {
+	return row.Scan(&b.ID, &b.Code, &b.Name, &b.Description, &b.Icon, &b.Metric,
+		&b.Threshold, &b.IsActive, &b.CreatedAt)
+}
+
+func (r *BadgeRepository) List() ([]*models.Badge, error) {
+	rows, err := r.db.Query(`SELECT ` + badgeColumns + ` FROM badges ORDER BY metric, threshold`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	badges := []*models.Badge{}
+	for rows.Next() {
+		b := &models.Badge{}
+		if err := scanBadge(rows, b); err != nil {
+			return nil, err
+		}
+		badges = append(badges, b)
+	}
+	return badges, rows.Err()
+}
+
+func (r *BadgeRepository) FindByID(id string) (*models.Badge, error) {
+	b := &models.Badge{}
+	err := scanBadge(r.db.QueryRow(`SELECT `+badgeColumns+` FROM badges WHERE id = $1`, id), b)
+	if err == sql.ErrNoRows {
+		return nil, ErrBadgeNotFound
+	}
+	return b, err
+}
+
+func (r *BadgeRepository) Create(b *models.Badge) error {
+	err := r.db.QueryRow(`
+		INSERT INTO badges (code, name, description, icon, metric, threshold, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, b.Code, b.Name, b.Description, b.Icon, b.Metric, b.Threshold, b.IsActive).Scan(&b.ID, &b.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrBadgeCodeTaken
+	}
+	return err
+}
+
+// Update changes how a badge is shown and earned. Badges already awarded
+// are kept even if the member no longer meets a raised threshold.
+func (r *BadgeRepository) Update(b *models.Badge) error {
+	res, err := r.db.Exec(`
+		UPDATE badges SET name = $2, description = $3, icon = $4, threshold = $5, is_active = $6
+		WHERE id = $1
+	`, b.ID, b.Name, b.Description, b.Icon, b.Threshold, b.IsActive)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBadgeNotFound
+	}
+	return nil
+}
+
+// ListForUser returns the badges a member has earned, newest first.
+func (r *BadgeRepository) ListForUser(userID string) ([]*models.UserBadge, error) {
+	rows, err := r.db.Query(`
+		SELECT ub.user_id, ub.awarded_at, b.id, b.code, b.name, b.description, b.icon, b.metric,
+		       b.threshold, b.is_active, b.created_at
+		FROM user_badges ub
+		JOIN badges b ON b.id = ub.badge_id
+		WHERE ub.user_id = $1
+		ORDER BY ub.awarded_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	badges := []*models.UserBadge{}
+	for rows.Next() {
+		ub := &models.UserBadge{}
+		if err := rows.Scan(&ub.UserID, &ub.AwardedAt, &ub.Badge.ID, &ub.Badge.Code, &ub.Badge.Name,
+			&ub.Badge.Description, &ub.Badge.Icon, &ub.Badge.Metric, &ub.Badge.Threshold,
+			&ub.Badge.IsActive, &ub.Badge.CreatedAt); err != nil {
+			return nil, err
+		}
+		badges = append(badges, ub)
+	}
+	return badges, rows.Err()
+}
+
+// AwardEarned awards every active badge on the metric that members have
+// reached and not been given yet, and returns only the new awards. With a
+// userID it checks that member alone. Awarding twice is a no-op, so it is
+// safe to call after any event and to re-run as a backfill.
+func (r *BadgeRepository) AwardEarned(metric models.BadgeMetric, userID string) ([]*models.UserBadge, error) {
+	totals, ok := metricTotals[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown badge metric: %s", metric)
+	}
+
+	args := []interface{}{metric}
+	filter := ""
+	if userID != "" {
+		args = append(args, userID)
+		filter = `WHERE t.user_id = $2`
+	}
+
+	rows, err := r.db.Query(`
+		WITH awarded AS (
+			INSERT INTO user_badges (user_id, badge_id)
+			SELECT t.user_id, b.id
+			FROM (`+totals+`) t(user_id, value)
+			JOIN badges b ON b.metric = $1 AND b.is_active AND t.value >= b.threshold
+			`+filter+`
+			ON CONFLICT (user_id, badge_id) DO NOTHING
+			RETURNING user_id, badge_id, awarded_at
+		)
+		SELECT a.user_id, a.awarded_at, b.id, b.code, b.name, b.description, b.icon, b.metric,
+		       b.threshold, b.is_active, b.created_at
+		FROM awarded a
+		JOIN badges b ON b.id = a.badge_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var awards []*models.UserBadge
+	for rows.Next() {
+		ub := &models.UserBadge{}
+		if err := rows.Scan(&ub.UserID, &ub.AwardedAt, &ub.Badge.ID, &ub.Badge.Code, &ub.Badge.Name,
+			&ub.Badge.Description, &ub.Badge.Icon, &ub.Badge.Metric, &ub.Badge.Threshold,
+			&ub.Badge.IsActive, &ub.Badge.CreatedAt); err != nil {
+			return nil, err
+		}
+		awards = append(awards, ub)
+	}
+	return awards, rows.Err()
+}
@@ -98,8 +98,16 @@ func (r *BookRepository) FindAll(filters map[string]interface{}) ([]*models.Book
 		argCount++
 	}
 
+	// A category also matches books filed under any of its subcategories
 	if category, ok := filters["category"].(string); ok && category != "" {
-		query += fmt.Sprintf(" AND category = $%d", argCount)
+		query += fmt.Sprintf(` AND category IN (
+			WITH RECURSIVE sub AS (
+				SELECT id, name FROM taxonomy_terms WHERE kind = 'category' AND name = $%[1]d
+				UNION ALL
+				SELECT t.id, t.name FROM taxonomy_terms t JOIN sub ON t.parent_id = sub.id
+			)
+			SELECT name FROM sub UNION SELECT $%[1]d
+		)`, argCount)
 		args = append(args, category)
 		argCount++
 	}
@@ -182,3 +190,218 @@ func (r *BookRepository) Delete(id string) error {
 	_, err := r.db.Exec(query, id)
 	return err
 }
+
+// NearbySearch describes a nearby search. Holders are measured at their
+// public point rather than their home: the home itself at exact privacy,
+// the centre of their CellDeg grid cell at neighbourhood privacy, and their
+// district's centre otherwise (the nearest centre if their district isn't
+// listed). Holders whose home is beyond SearchKm are never considered, which
+// keeps the search on the location index.
+type NearbySearch struct {
+	Lat, Lng     float64
+	RadiusKm     float64
+	SearchKm     float64
+	Limit        int
+	CellDeg      float64
+	Districts    []string
+	DistrictLats []float64
+	DistrictLngs []float64
+}
+
+// FindNearby returns available books whose current holder (or, for books on
+// the shelf, the member who added them) has a public point within RadiusKm,
+// closest first. Holders who hide their location are left out. DistanceKm is
+// the exact distance to the public point, so results must still be bucketed
+// with services.BucketNearby before they are shown.
+func (r *BookRepository) FindNearby(s NearbySearch) ([]*models.NearbyBook, error) {
+	query := `
+		WITH centres AS (
+		    SELECT * FROM unnest($7::text[], $8::float8[], $9::float8[]) AS c(name, lat, lng)
+		)
+		SELECT b.id, b.title, b.author, COALESCE(b.isbn, '') as isbn, COALESCE(b.cover_url, '') as cover_url, 
+		       COALESCE(b.description, '') as description, COALESCE(b.category, '') as category, 
+		       COALESCE(b.tags, '{}') as tags, COALESCE(b.topics, '{}') as topics, 
+		       b.physical_code, b.status, b.current_holder_id, b.created_by, b.donated_by, 
+		       b.is_donated, b.donation_date, b.total_reads, b.average_rating, b.created_at, b.updated_at,
+		       d.km, COALESCE(u.location_privacy, 'district')
+		FROM books b
+		JOIN users u ON u.id = COALESCE(b.current_holder_id, b.created_by)
+		LEFT JOIN centres dc ON dc.name = u.location_district
+		LEFT JOIN LATERAL (
+		    SELECT c.lat, c.lng FROM centres c
+		    WHERE dc.name IS NULL
+		    ORDER BY earth_distance(ll_to_earth(c.lat, c.lng), ll_to_earth(u.location_lat::float8, u.location_lng::float8))
+		    LIMIT 1
+		) nc ON COALESCE(u.location_privacy, 'district') = 'district'
+		CROSS JOIN LATERAL (
+		    SELECT CASE COALESCE(u.location_privacy, 'district')
+		               WHEN 'exact' THEN u.location_lat::float8
+		               WHEN 'neighbourhood' THEN floor(u.location_lat::float8 / $6) * $6 + $6 / 2
+		               ELSE COALESCE(dc.lat, nc.lat)
+		           END as lat,
+		           CASE COALESCE(u.location_privacy, 'district')
+		               WHEN 'exact' THEN u.location_lng::float8
+		               WHEN 'neighbourhood' THEN floor(u.location_lng::float8 / $6) * $6 + $6 / 2
+		               ELSE COALESCE(dc.lng, nc.lng)
+		           END as lng
+		) p
+		CROSS JOIN LATERAL (
+		    SELECT earth_distance(ll_to_earth($1, $2), ll_to_earth(p.lat, p.lng)) / 1000 as km
+		) d
+		WHERE b.status = 'available'
+		  AND u.location_lat IS NOT NULL AND u.location_lng IS NOT NULL
+		  AND COALESCE(u.location_privacy, 'district') <> 'hidden'
+		  AND earth_box(ll_to_earth($1, $2), $4) @> ll_to_earth(u.location_lat::float8, u.location_lng::float8)
+		  AND p.lat IS NOT NULL
+		  AND d.km <= $3
+		ORDER BY d.km ASC, b.created_at DESC
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(query, s.Lat, s.Lng, s.RadiusKm, s.SearchKm*1000, s.Limit, s.CellDeg,
+		pq.Array(s.Districts), pq.Array(s.DistrictLats), pq.Array(s.DistrictLngs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []*models.NearbyBook{}
+	for rows.Next() {
+		book := &models.NearbyBook{}
+		err := rows.Scan(
+			&book.ID,
+			&book.Title,
+			&book.Author,
+			&book.ISBN,
+			&book.CoverURL,
+			&book.Description,
+			&book.Category,
+			pq.Array(&book.Tags),
+			pq.Array(&book.Topics),
+			&book.PhysicalCode,
+			&book.Status,
+			&book.CurrentHolderID,
+			&book.CreatedBy,
+			&book.DonatedBy,
+			&book.IsDonated,
+			&book.DonationDate,
+			&book.TotalReads,
+			&book.AverageRating,
+			&book.CreatedAt,
+			&book.UpdatedAt,
+			&book.DistanceKm,
+			&book.HolderPrivacy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+
+	return books, nil
+}
+
+// FindSimilar returns the precomputed most similar books to bookID.
+func (r *BookRepository) FindSimilar(bookID string, limit int) ([]*models.SimilarBook, error) {
+	query := `
+		SELECT b.id, b.title, b.author, COALESCE(b.isbn, '') as isbn, COALESCE(b.cover_url, '') as cover_url, 
+		       COALESCE(b.description, '') as description, COALESCE(b.category, '') as category, 
+		       COALESCE(b.tags, '{}') as tags, COALESCE(b.topics, '{}') as topics, 
+		       b.physical_code, b.status, b.current_holder_id, b.created_by, b.donated_by, 
+		       b.is_donated, b.donation_date, b.total_reads, b.average_rating, b.created_at, b.updated_at,
+		       s.score, s.topic_score, s.tag_score, s.same_author, s.same_category, s.coreader_score
+		FROM book_similarities s
+		JOIN books b ON b.id = s.similar_book_id
+		WHERE s.book_id = $1
+		ORDER BY s.score DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(query, bookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []*models.SimilarBook{}
+	for rows.Next() {
+		book := &models.SimilarBook{}
+		err := rows.Scan(
+			&book.ID,
+			&book.Title,
+			&book.Author,
+			&book.ISBN,
+			&book.CoverURL,
+			&book.Description,
+			&book.Category,
+			pq.Array(&book.Tags),
+			pq.Array(&book.Topics),
+			&book.PhysicalCode,
+			&book.Status,
+			&book.CurrentHolderID,
+			&book.CreatedBy,
+			&book.DonatedBy,
+			&book.IsDonated,
+			&book.DonationDate,
+			&book.TotalReads,
+			&book.AverageRating,
+			&book.CreatedAt,
+			&book.UpdatedAt,
+			&book.Similarity,
+			&book.TopicScore,
+			&book.TagScore,
+			&book.SameAuthor,
+			&book.SameCategory,
+			&book.CoreaderScore,
+		)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+
+	return books, rows.Err()
+}
+
+// ReplaceSimilarities swaps the whole similarity table for a freshly
+// computed one in a single transaction.
+func (r *BookRepository) ReplaceSimilarities(sims []models.BookSimilarity) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM book_similarities`); err != nil {
+		return err
+	}
+
+	if len(sims) > 0 {
+		var bookIDs, similarIDs []string
+		var scores, topicScores, tagScores, coreaderScores []float64
+		var sameAuthor, sameCategory []bool
+		for _, s := range sims {
+			bookIDs = append(bookIDs, s.BookID)
+			similarIDs = append(similarIDs, s.SimilarBookID)
+			scores = append(scores, s.Score)
+			topicScores = append(topicScores, s.TopicScore)
+			tagScores = append(tagScores, s.TagScore)
+			sameAuthor = append(sameAuthor, s.SameAuthor)
+			sameCategory = append(sameCategory, s.SameCategory)
+			coreaderScores = append(coreaderScores, s.CoreaderScore)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO book_similarities (book_id, similar_book_id, score, topic_score, tag_score,
+				same_author, same_category, coreader_score)
+			SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::float8[], $4::float8[], $5::float8[],
+				$6::bool[], $7::bool[], $8::float8[])
+		`, pq.Array(bookIDs), pq.Array(similarIDs), pq.Array(scores), pq.Array(topicScores),
+			pq.Array(tagScores), pq.Array(sameAuthor), pq.Array(sameCategory), pq.Array(coreaderScores))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+var (
+	ErrCommentNotFound = fmt.Errorf("comment not found")
+	ErrSelfCommentVote = fmt.Errorf("you cannot vote on your own comment")
+)
+
+const commentSelect = `
+	SELECT c.id, c.idea_id, c.user_id, u.username, c.parent_id, c.depth, c.content,
+	       c.upvotes, c.downvotes, c.deleted_at IS NOT NULL, c.hidden_at IS NOT NULL, c.edited_at, c.created_at
+	FROM idea_comments c
+	JOIN users u ON u.id = c.user_id
+`
+
+func scanComment(row interface{ Scan(...interface{}) error }) (*models.IdeaComment, error) {
+	c := &models.IdeaComment{Replies: []*models.IdeaComment{}}
+	err := row.Scan(&c.ID, &c.IdeaID, &c.UserID, &c.Username, &c.ParentID, &c.Depth, &c.Content,
+		&c.Upvotes, &c.Downvotes, &c.IsDeleted, &c.IsHidden, &c.EditedAt, &c.CreatedAt)
+	return c, err
+}
+
+// redact hides what a deleted comment said and who said it, and what a
+// comment hidden by moderation said.
+func redact(c *models.IdeaComment) {
+	if c.IsDeleted {
+		c.UserID, c.Username, c.Content = "", "", ""
+	}
+	if c.IsHidden {
+		c.Content = ""
+	}
+}
+
+// Create stores a comment and bumps the idea's comment count.
+func (r *CommentRepository) Create(comment *models.IdeaComment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO idea_comments (idea_id, user_id, parent_id, depth, content)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, comment.IdeaID, comment.UserID, comment.ParentID, comment.Depth, comment.Content).
+		Scan(&comment.ID, &comment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE reading_ideas SET comment_count = comment_count + 1 WHERE id = $1`, comment.IdeaID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CommentRepository) FindByID(id string) (*models.IdeaComment, error) {
+	c, err := scanComment(r.db.QueryRow(commentSelect+` WHERE c.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	return c, err
+}
+
+func (r *CommentRepository) UpdateContent(comment *models.IdeaComment) error {
+	return r.db.QueryRow(`
+		UPDATE idea_comments SET content = $1, edited_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND deleted_at IS NULL
+		RETURNING edited_at
+	`, comment.Content, comment.ID).Scan(&comment.EditedAt)
+}
+
+// SoftDelete marks a comment deleted and takes it out of the idea's count.
+// Replies are kept.
+func (r *CommentRepository) SoftDelete(comment *models.IdeaComment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.SoftDeleteTx(tx, comment); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SoftDeleteTx is SoftDelete inside the caller's transaction.
+func (r *CommentRepository) SoftDeleteTx(tx *sql.Tx, comment *models.IdeaComment) error {
+	res, err := tx.Exec(`
+		UPDATE idea_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL
+	`, comment.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCommentNotFound
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reading_ideas SET comment_count = GREATEST(comment_count - 1, 0) WHERE id = $1
+	`, comment.IdeaID)
+	return err
+}
+
+// ListThreads returns one page of top-level comments on an idea, each with
+// its full reply tree (replies oldest first), plus the number of top-level
+// comments. sort is "newest" or "top".
+func (r *CommentRepository) ListThreads(ideaID, sort string, limit, offset int) ([]*models.IdeaComment, int, error) {
+	var total int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM idea_comments WHERE idea_id = $1 AND parent_id IS NULL
+	`, ideaID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order := "c.created_at DESC"
+	if sort == "top" {
+		order = "(c.upvotes - c.downvotes) DESC, c.created_at DESC"
+	}
+
+	rows, err := r.db.Query(commentSelect+`
+		WHERE c.idea_id = $1 AND c.parent_id IS NULL
+		ORDER BY `+order+`
+		LIMIT $2 OFFSET $3
+	`, ideaID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	roots := []*models.IdeaComment{}
+	byID := make(map[string]*models.IdeaComment)
+	rootIDs := []string{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		redact(c)
+		roots = append(roots, c)
+		byID[c.ID] = c
+		rootIDs = append(rootIDs, c.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(rootIDs) == 0 {
+		return roots, total, nil
+	}
+
+	// Depth-ordered so every parent is seen before its replies
+	rows, err = r.db.Query(`
+		WITH RECURSIVE thread AS (
+			SELECT id FROM idea_comments WHERE parent_id = ANY($1::uuid[])
+			UNION ALL
+			SELECT c.id FROM idea_comments c JOIN thread t ON c.parent_id = t.id
+		)
+	`+commentSelect+`
+		WHERE c.id IN (SELECT id FROM thread)
+		ORDER BY c.depth, c.created_at
+	`, pq.Array(rootIDs))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		redact(c)
+		byID[c.ID] = c
+		if parent, ok := byID[c.ParentID.String]; ok {
+			parent.Replies = append(parent.Replies, c)
+		}
+	}
+	return roots, total, rows.Err()
+}
+
+// Vote sets userID's vote on a comment, moving the counters by the net change.
+func (r *CommentRepository) Vote(commentID, userID, voteType string) (*models.VoteChange, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	change := &models.VoteChange{Previous: models.VoteNone, Current: voteType}
+	err = tx.QueryRow(`
+		SELECT idea_id, user_id, upvotes, downvotes FROM idea_comments
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, commentID).Scan(&change.IdeaID, &change.AuthorID, &change.Upvotes, &change.Downvotes)
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if change.AuthorID == userID {
+		return nil, ErrSelfCommentVote
+	}
+
+	err = tx.QueryRow(`
+		SELECT vote_type FROM comment_votes WHERE comment_id = $1 AND user_id = $2
+	`, commentID, userID).Scan(&change.Previous)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if change.Previous == change.Current {
+		return change, nil
+	}
+
+	if voteType == models.VoteNone {
+		_, err = tx.Exec(`DELETE FROM comment_votes WHERE comment_id = $1 AND user_id = $2`, commentID, userID)
+	} else {
+		_, err = tx.Exec(`
+			INSERT INTO comment_votes (comment_id, user_id, vote_type)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (comment_id, user_id) DO UPDATE SET vote_type = $3, created_at = CURRENT_TIMESTAMP
+		`, commentID, userID, voteType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	up, down := voteCounts(change.Current)
+	prevUp, prevDown := voteCounts(change.Previous)
+	err = tx.QueryRow(`
+		UPDATE idea_comments SET upvotes = upvotes + $2, downvotes = downvotes + $3
+		WHERE id = $1
+		RETURNING upvotes, downvotes
+	`, commentID, up-prevUp, down-prevDown).Scan(&change.Upvotes, &change.Downvotes)
+	if err != nil {
+		return nil, err
+	}
+
+	return change, tx.Commit()
+}
@@ -26,7 +26,8 @@ func (r *DonationRepository) Create(donation *models.Donation) error {
 
 func (r *DonationRepository) GetAll(limit, offset int, publicOnly bool) ([]*models.Donation, error) {
 	query := `
-		SELECT id, donor_id, donation_type, book_id, amount, currency, message, is_public, created_at
+		SELECT id, donor_id, donation_type, book_id, amount, currency,
+		       CASE WHEN hidden_at IS NULL THEN message ELSE '' END, is_public, created_at
 		FROM donations
 	`
 	if publicOnly {
@@ -55,7 +56,8 @@ func (r *DonationRepository) GetAll(limit, offset int, publicOnly bool) ([]*mode
 
 func (r *DonationRepository) GetByDonor(donorID string) ([]*models.Donation, error) {
 	rows, err := r.db.Query(`
-		SELECT id, donor_id, donation_type, book_id, amount, currency, message, is_public, created_at
+		SELECT id, donor_id, donation_type, book_id, amount, currency,
+		       CASE WHEN hidden_at IS NULL THEN message ELSE '' END, is_public, created_at
 		FROM donations
 		WHERE donor_id = $1
 		ORDER BY created_at DESC
@@ -2,7 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/yourusername/online-library/internal/models"
 )
 
@@ -14,32 +18,79 @@ func NewIdeaRepository(db *sql.DB) *IdeaRepository {
 	return &IdeaRepository{db: db}
 }
 
+var (
+	ErrIdeaNotFound = fmt.Errorf("idea not found")
+	ErrSelfVote     = fmt.Errorf("you cannot vote on your own idea")
+)
+
+// Create stores an idea together with its first revision and counts it
+// towards the author's ideas_posted.
 func (r *IdeaRepository) Create(idea *models.ReadingIdea) error {
-	return r.db.QueryRow(`
-		INSERT INTO reading_ideas (book_id, user_id, title, content)
-		VALUES ($1, $2, $3, $4)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO reading_ideas (book_id, user_id, title, content, chapter, page_start, page_end, quote)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, NULLIF($8, ''))
 		RETURNING id, created_at, updated_at
-	`, idea.BookID, idea.UserID, idea.Title, idea.Content).Scan(&idea.ID, &idea.CreatedAt, &idea.UpdatedAt)
+	`, idea.BookID, idea.UserID, idea.Title, idea.Content, idea.Chapter, idea.PageStart, idea.PageEnd, idea.Quote).
+		Scan(&idea.ID, &idea.CreatedAt, &idea.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO idea_revisions (idea_id, revision, title, content, edited_by, created_at)
+		VALUES ($1, 1, $2, $3, $4, $5)
+	`, idea.ID, idea.Title, idea.Content, idea.UserID, idea.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET ideas_posted = ideas_posted + 1 WHERE id = $1`, idea.UserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *IdeaRepository) GetByID(id string) (*models.ReadingIdea, error) {
 	idea := &models.ReadingIdea{}
 	err := r.db.QueryRow(`
-		SELECT id, book_id, user_id, title, content, upvotes, downvotes, created_at, updated_at
-		FROM reading_ideas WHERE id = $1
+		SELECT id, book_id, user_id, title, content, COALESCE(chapter, ''), page_start, page_end,
+		       COALESCE(quote, ''), upvotes, downvotes, comment_count, edited_at, hidden_at, created_at, updated_at
+		FROM reading_ideas WHERE id = $1 AND deleted_at IS NULL
 	`, id).Scan(&idea.ID, &idea.BookID, &idea.UserID, &idea.Title, &idea.Content,
-		&idea.Upvotes, &idea.Downvotes, &idea.CreatedAt, &idea.UpdatedAt)
+		&idea.Chapter, &idea.PageStart, &idea.PageEnd, &idea.Quote,
+		&idea.Upvotes, &idea.Downvotes, &idea.CommentCount, &idea.EditedAt, &idea.HiddenAt, &idea.CreatedAt, &idea.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrIdeaNotFound
+	}
 	return idea, err
 }
 
-func (r *IdeaRepository) GetByBook(bookID string, limit, offset int) ([]*models.ReadingIdea, error) {
+// IdeaAnchorFilter narrows a book's ideas to a chapter (case-insensitive)
+// and/or to ideas whose page range covers Page. Zero values match all.
+type IdeaAnchorFilter struct {
+	Chapter string
+	Page    int
+}
+
+func (r *IdeaRepository) GetByBook(bookID string, f IdeaAnchorFilter, limit, offset int) ([]*models.ReadingIdea, error) {
 	rows, err := r.db.Query(`
-		SELECT id, book_id, user_id, title, content, upvotes, downvotes, created_at, updated_at
+		SELECT id, book_id, user_id, title, content, COALESCE(chapter, ''), page_start, page_end,
+		       COALESCE(quote, ''), upvotes, downvotes, comment_count, edited_at, created_at, updated_at
 		FROM reading_ideas
-		WHERE book_id = $1
+		WHERE book_id = $1 AND deleted_at IS NULL AND hidden_at IS NULL
+		  AND ($2 = '' OR LOWER(chapter) = LOWER($2))
+		  AND ($3 = 0 OR $3 BETWEEN page_start AND COALESCE(page_end, page_start))
 		ORDER BY (upvotes - downvotes) DESC, created_at DESC
-		LIMIT $2 OFFSET $3
-	`, bookID, limit, offset)
+		LIMIT $4 OFFSET $5
+	`, bookID, f.Chapter, f.Page, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +100,8 @@ func (r *IdeaRepository) GetByBook(bookID string, limit, offset int) ([]*models.
 	for rows.Next() {
 		idea := &models.ReadingIdea{}
 		err := rows.Scan(&idea.ID, &idea.BookID, &idea.UserID, &idea.Title, &idea.Content,
-			&idea.Upvotes, &idea.Downvotes, &idea.CreatedAt, &idea.UpdatedAt)
+			&idea.Chapter, &idea.PageStart, &idea.PageEnd, &idea.Quote,
+			&idea.Upvotes, &idea.Downvotes, &idea.CommentCount, &idea.EditedAt, &idea.CreatedAt, &idea.UpdatedAt)
 		if err != nil {
 			continue
 		}
@@ -58,42 +110,384 @@ func (r *IdeaRepository) GetByBook(bookID string, limit, offset int) ([]*models.
 	return ideas, nil
 }
 
-func (r *IdeaRepository) Vote(ideaID, userID, voteType string) error {
+// IdeaFeedFilter selects and orders ideas for the idea feed. Sort is "new",
+// "top" or "hot"; Since limits "top" to a period; ForUserID restricts the
+// feed to books that user has read or bookmarked.
+type IdeaFeedFilter struct {
+	Sort      string
+	Since     *time.Time
+	BookID    string
+	AuthorID  string
+	Topic     string
+	ForUserID string
+	After     *IdeaCursor
+	Limit     int
+}
+
+// IdeaCursor is the position of the last idea on a feed page. Score is the
+// ranking value as an exact decimal string so it compares equal in SQL.
+type IdeaCursor struct {
+	Score     string    `json:"s"`
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// feedScores are the ranking expressions per sort. "hot" is log10 of the net
+// votes plus creation time in units of 12.5 hours, so a new idea needs ten
+// times the votes to outrank one posted 12.5 hours earlier. It does not
+// depend on the current time, which keeps cursors stable.
+var feedScores = map[string]string{
+	"new": `0::numeric`,
+	"top": `(i.upvotes - i.downvotes)::numeric`,
+	"hot": `ROUND((SIGN(i.upvotes - i.downvotes) * LOG(GREATEST(ABS(i.upvotes - i.downvotes), 1))
+		+ EXTRACT(EPOCH FROM i.created_at) / 45000)::numeric, 6)`,
+}
+
+// Feed returns one page of ideas across books, and the cursor for the next
+// page (nil on the last page).
+func (r *IdeaRepository) Feed(f IdeaFeedFilter) ([]*models.ReadingIdea, *IdeaCursor, error) {
+	score, ok := feedScores[f.Sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown sort: %s", f.Sort)
+	}
+
+	where := []string{"i.deleted_at IS NULL", "i.hidden_at IS NULL"}
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.Since != nil {
+		where = append(where, "i.created_at >= "+arg(*f.Since))
+	}
+	if f.BookID != "" {
+		where = append(where, "i.book_id = "+arg(f.BookID))
+	}
+	if f.AuthorID != "" {
+		where = append(where, "i.user_id = "+arg(f.AuthorID))
+	}
+	if f.Topic != "" {
+		where = append(where, arg(f.Topic)+" = ANY(b.topics)")
+	}
+	if f.ForUserID != "" {
+		p := arg(f.ForUserID)
+		where = append(where, `i.book_id IN (
+			SELECT book_id FROM reading_history WHERE reader_id = `+p+`
+			UNION
+			SELECT book_id FROM user_bookmarks WHERE user_id = `+p+`
+		)`)
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT i.id, i.book_id, b.title as book_title, i.user_id, u.username, i.title, i.content,
+			       COALESCE(i.chapter, ''), i.page_start, i.page_end, COALESCE(i.quote, ''),
+			       i.upvotes, i.downvotes, i.comment_count, i.edited_at, i.created_at, i.updated_at,
+			       ` + score + ` as rank_score
+			FROM reading_ideas i
+			JOIN books b ON b.id = i.book_id
+			JOIN users u ON u.id = i.user_id
+			WHERE ` + strings.Join(where, " AND ") + `
+		) f`
+	if f.After != nil {
+		query += fmt.Sprintf(" WHERE (f.rank_score, f.created_at, f.id) < (%s::numeric, %s, %s::uuid)",
+			arg(f.After.Score), arg(f.After.CreatedAt), arg(f.After.ID))
+	}
+	query += " ORDER BY f.rank_score DESC, f.created_at DESC, f.id DESC LIMIT " + arg(f.Limit+1)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	ideas := []*models.ReadingIdea{}
+	var scores []string
+	for rows.Next() {
+		idea := &models.ReadingIdea{Book: &models.Book{}, User: &models.User{}}
+		var rankScore string
+		err := rows.Scan(&idea.ID, &idea.BookID, &idea.Book.Title, &idea.UserID, &idea.User.Username,
+			&idea.Title, &idea.Content, &idea.Chapter, &idea.PageStart, &idea.PageEnd, &idea.Quote,
+			&idea.Upvotes, &idea.Downvotes, &idea.CommentCount, &idea.EditedAt, &idea.CreatedAt, &idea.UpdatedAt, &rankScore)
+		if err != nil {
+			return nil, nil, err
+		}
+		idea.Book.ID, idea.User.ID = idea.BookID, idea.UserID
+		ideas = append(ideas, idea)
+		scores = append(scores, rankScore)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(ideas) <= f.Limit {
+		return ideas, nil, nil
+	}
+	ideas = ideas[:f.Limit]
+	last := ideas[len(ideas)-1]
+	return ideas, &IdeaCursor{Score: scores[f.Limit-1], CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
+
+// Vote sets userID's vote on an idea to voteType (VoteNone removes it). The
+// idea row is locked so concurrent votes see each other's result, and the
+// idea counters and the author's vote totals move by the net change only.
+// score applies the change to the author's success score in the same
+// transaction, so each net change is scored exactly once and in order.
+func (r *IdeaRepository) Vote(ideaID, userID, voteType string, score func(tx *sql.Tx, change *models.VoteChange) error) (*models.VoteChange, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Remove existing vote
-	_, err = tx.Exec(`DELETE FROM idea_votes WHERE idea_id = $1 AND user_id = $2`, ideaID, userID)
+	change := &models.VoteChange{IdeaID: ideaID, Previous: models.VoteNone, Current: voteType}
+	err = tx.QueryRow(`
+		SELECT user_id, title, upvotes, downvotes FROM reading_ideas
+		WHERE id = $1 AND deleted_at IS NULL AND hidden_at IS NULL
+		FOR UPDATE
+	`, ideaID).Scan(&change.AuthorID, &change.IdeaTitle, &change.Upvotes, &change.Downvotes)
+	if err == sql.ErrNoRows {
+		return nil, ErrIdeaNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if change.AuthorID == userID {
+		return nil, ErrSelfVote
+	}
+
+	err = tx.QueryRow(`
+		SELECT vote_type FROM idea_votes WHERE idea_id = $1 AND user_id = $2
+	`, ideaID, userID).Scan(&change.Previous)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if change.Previous == change.Current {
+		return change, nil
+	}
+
+	if voteType == models.VoteNone {
+		_, err = tx.Exec(`DELETE FROM idea_votes WHERE idea_id = $1 AND user_id = $2`, ideaID, userID)
+	} else {
+		_, err = tx.Exec(`
+			INSERT INTO idea_votes (idea_id, user_id, vote_type)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (idea_id, user_id) DO UPDATE SET vote_type = $3, created_at = CURRENT_TIMESTAMP
+		`, ideaID, userID, voteType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	up, down := voteCounts(change.Current)
+	prevUp, prevDown := voteCounts(change.Previous)
+	up, down = up-prevUp, down-prevDown
+
+	err = tx.QueryRow(`
+		UPDATE reading_ideas SET upvotes = upvotes + $2, downvotes = downvotes + $3
+		WHERE id = $1
+		RETURNING upvotes, downvotes
+	`, ideaID, up, down).Scan(&change.Upvotes, &change.Downvotes)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add new vote
 	_, err = tx.Exec(`
-		INSERT INTO idea_votes (idea_id, user_id, vote_type)
-		VALUES ($1, $2, $3)
-	`, ideaID, userID, voteType)
+		UPDATE users SET total_upvotes = total_upvotes + $2, total_downvotes = total_downvotes + $3
+		WHERE id = $1
+	`, change.AuthorID, up, down)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.QueryRow(`SELECT username FROM users WHERE id = $1`, userID).Scan(&change.VoterName); err != nil {
+		return nil, err
+	}
+
+	if err := score(tx, change); err != nil {
+		return nil, err
+	}
+
+	return change, tx.Commit()
+}
+
+// Update saves a new version of an idea and records it as the next
+// revision, with diffs against the previous version. rev is nil when only
+// the anchor changed, which is saved without a revision.
+func (r *IdeaRepository) Update(idea *models.ReadingIdea, rev *models.IdeaRevision) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	if rev == nil {
+		err = tx.QueryRow(`
+			UPDATE reading_ideas
+			SET chapter = NULLIF($1, ''), page_start = $2, page_end = $3, quote = NULLIF($4, '')
+			WHERE id = $5 AND deleted_at IS NULL
+			RETURNING updated_at
+		`, idea.Chapter, idea.PageStart, idea.PageEnd, idea.Quote, idea.ID).Scan(&idea.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return ErrIdeaNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
 
-	// Update counts
+	// Ideas posted before revisions were tracked get their original saved first
 	_, err = tx.Exec(`
+		INSERT INTO idea_revisions (idea_id, revision, title, content, edited_by, created_at)
+		SELECT id, 1, title, content, user_id, created_at FROM reading_ideas
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM idea_revisions WHERE idea_id = $1)
+	`, idea.ID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
 		UPDATE reading_ideas
-		SET upvotes = (SELECT COUNT(*) FROM idea_votes WHERE idea_id = $1 AND vote_type = 'upvote'),
-		    downvotes = (SELECT COUNT(*) FROM idea_votes WHERE idea_id = $1 AND vote_type = 'downvote')
-		WHERE id = $1
-	`, ideaID)
+		SET title = $1, content = $2, chapter = NULLIF($3, ''), page_start = $4, page_end = $5,
+		    quote = NULLIF($6, ''), edited_at = CURRENT_TIMESTAMP
+		WHERE id = $7 AND deleted_at IS NULL
+		RETURNING edited_at, updated_at
+	`, idea.Title, idea.Content, idea.Chapter, idea.PageStart, idea.PageEnd, idea.Quote, idea.ID).
+		Scan(&idea.EditedAt, &idea.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrIdeaNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO idea_revisions (idea_id, revision, title, content, title_diff, content_diff, edited_by)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6
+		FROM idea_revisions WHERE idea_id = $1
+		RETURNING id, revision, created_at
+	`, idea.ID, rev.Title, rev.Content, rev.TitleDiff, rev.ContentDiff, rev.EditedBy).
+		Scan(&rev.ID, &rev.Revision, &rev.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete hides an idea and takes it off the author's ideas_posted count.
+// Revisions, votes and comments are kept.
+func (r *IdeaRepository) Delete(idea *models.ReadingIdea, deletedBy string) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
+	if err := r.DeleteTx(tx, idea, deletedBy); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
+// DeleteTx is Delete inside the caller's transaction.
+func (r *IdeaRepository) DeleteTx(tx *sql.Tx, idea *models.ReadingIdea, deletedBy string) error {
+	res, err := tx.Exec(`
+		UPDATE reading_ideas SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, idea.ID, deletedBy)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrIdeaNotFound
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET ideas_posted = GREATEST(ideas_posted - 1, 0) WHERE id = $1
+	`, idea.UserID)
+	return err
+}
+
+// GetHighlights returns a book's most upvoted quotes. Ideas quoting the same
+// passage (ignoring case and whitespace) count as one highlight.
+func (r *IdeaRepository) GetHighlights(bookID, chapter string, limit int) ([]*models.Highlight, error) {
+	rows, err := r.db.Query(`
+		WITH q AS (
+			SELECT id, quote, COALESCE(chapter, '') as chapter, page_start, page_end,
+			       upvotes - downvotes as net, created_at,
+			       LOWER(REGEXP_REPLACE(BTRIM(quote), '\s+', ' ', 'g')) as passage
+			FROM reading_ideas
+			WHERE book_id = $1 AND deleted_at IS NULL AND hidden_at IS NULL AND quote IS NOT NULL
+			  AND ($2 = '' OR LOWER(chapter) = LOWER($2))
+		)
+		SELECT (ARRAY_AGG(quote ORDER BY net DESC, created_at))[1],
+		       (ARRAY_AGG(chapter ORDER BY net DESC, created_at))[1],
+		       (ARRAY_AGG(page_start ORDER BY net DESC, created_at))[1],
+		       (ARRAY_AGG(page_end ORDER BY net DESC, created_at))[1],
+		       SUM(net), COUNT(*), ARRAY_AGG(id ORDER BY net DESC, created_at)
+		FROM q
+		GROUP BY passage
+		ORDER BY SUM(net) DESC, COUNT(*) DESC, MIN(created_at)
+		LIMIT $3
+	`, bookID, chapter, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	highlights := []*models.Highlight{}
+	for rows.Next() {
+		h := &models.Highlight{}
+		err := rows.Scan(&h.Quote, &h.Chapter, &h.PageStart, &h.PageEnd,
+			&h.NetScore, &h.IdeaCount, pq.Array(&h.IdeaIDs))
+		if err != nil {
+			return nil, err
+		}
+		highlights = append(highlights, h)
+	}
+	return highlights, rows.Err()
+}
+
+// GetRevisions returns an idea's history, newest first.
+func (r *IdeaRepository) GetRevisions(ideaID string) ([]*models.IdeaRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT id, idea_id, revision, title, content, COALESCE(title_diff, ''),
+		       COALESCE(content_diff, ''), edited_by, created_at
+		FROM idea_revisions
+		WHERE idea_id = $1
+		ORDER BY revision DESC
+	`, ideaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.IdeaRevision{}
+	for rows.Next() {
+		rev := &models.IdeaRevision{}
+		err := rows.Scan(&rev.ID, &rev.IdeaID, &rev.Revision, &rev.Title, &rev.Content,
+			&rev.TitleDiff, &rev.ContentDiff, &rev.EditedBy, &rev.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+func voteCounts(voteType string) (up, down int) {
+	switch voteType {
+	case models.VoteUp:
+		return 1, 0
+	case models.VoteDown:
+		return 0, 1
+	}
+	return 0, 0
+}
+
 func (r *IdeaRepository) GetUserVote(ideaID, userID string) (string, error) {
 	var voteType string
 	err := r.db.QueryRow(`
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+// MaxInterestWeight caps how far implicit feedback can push a weight.
+const MaxInterestWeight = 5.0
+
+type InterestRepository struct {
+	db *sql.DB
+}
+
+func NewInterestRepository(db *sql.DB) *InterestRepository {
+	return &InterestRepository{db: db}
+}
+
+func (r *InterestRepository) GetByUser(userID string) ([]*models.UserInterest, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, interest, weight, explicit_weight, last_signal_at, created_at, updated_at
+		FROM user_interests
+		WHERE user_id = $1
+		ORDER BY weight DESC, interest
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	interests := []*models.UserInterest{}
+	for rows.Next() {
+		i := &models.UserInterest{}
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Interest, &i.Weight, &i.ExplicitWeight,
+			&i.LastSignalAt, &i.CreatedAt, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		interests = append(interests, i)
+	}
+	return interests, rows.Err()
+}
+
+// SetWeight records an explicit weight chosen by the member.
+func (r *InterestRepository) SetWeight(userID, interest string, weight float64) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_interests (user_id, interest, weight, explicit_weight)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (user_id, interest)
+		DO UPDATE SET weight = $3, explicit_weight = $3
+	`, userID, interest, weight)
+	return err
+}
+
+func (r *InterestRepository) Delete(userID, interest string) error {
+	res, err := r.db.Exec(`DELETE FROM user_interests WHERE user_id = $1 AND interest = $2`, userID, interest)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("interest not found")
+	}
+	return nil
+}
+
+// Nudge adds amount to the member's weight for each topic, creating learned
+// interests as needed.
+func (r *InterestRepository) Nudge(userID string, topics []string, amount float64) error {
+	if len(topics) == 0 {
+		return nil
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO user_interests (user_id, interest, weight, last_signal_at)
+		SELECT $1, t.topic, LEAST($3, $4), CURRENT_TIMESTAMP
+		FROM (SELECT DISTINCT unnest($2::text[]) as topic) t
+		ON CONFLICT (user_id, interest)
+		DO UPDATE SET weight = LEAST($4, user_interests.weight + $3), last_signal_at = CURRENT_TIMESTAMP
+	`, userID, pq.Array(topics), amount, MaxInterestWeight)
+	return err
+}
+
+// Decay pulls every learned weight back towards the member's explicit weight
+// (or zero) by factor(elapsed), where elapsed is the time since the last
+// decay, and drops learned-only interests that have faded out. The last run
+// is stored in interest_decay_state and locked for the duration, so
+// concurrent instances don't decay the same interval twice. Weights within
+// 0.02 of their floor snap to it so rounding can't stall them.
+func (r *InterestRepository) Decay(factor func(elapsed time.Duration) float64, dropBelow float64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO interest_decay_state DEFAULT VALUES ON CONFLICT (id) DO NOTHING`)
+	if err != nil {
+		return err
+	}
+
+	var last, now time.Time
+	err = tx.QueryRow(`
+		SELECT last_decayed_at, clock_timestamp()::timestamp FROM interest_decay_state FOR UPDATE
+	`).Scan(&last, &now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_interests
+		SET weight = CASE
+		    WHEN ABS(weight - COALESCE(explicit_weight, 0)) < 0.02 THEN COALESCE(explicit_weight, 0)
+		    ELSE COALESCE(explicit_weight, 0) + (weight - COALESCE(explicit_weight, 0)) * $1
+		END
+		WHERE weight <> COALESCE(explicit_weight, 0)
+	`, factor(max(now.Sub(last), 0)))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM user_interests WHERE explicit_weight IS NULL AND weight < $1
+	`, dropBelow)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE interest_decay_state SET last_decayed_at = $1`, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// NudgeFromReads applies amount per read to the topics of every reading
+// history entry not yet counted, then marks those entries as counted.
+func (r *InterestRepository) NudgeFromReads(amount float64) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id FROM reading_history WHERE interest_signal_at IS NULL FOR UPDATE SKIP LOCKED
+	`)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_interests (user_id, interest, weight, last_signal_at)
+		SELECT rh.reader_id, t.topic, LEAST($3, COUNT(*) * $2), CURRENT_TIMESTAMP
+		FROM reading_history rh
+		JOIN books b ON b.id = rh.book_id
+		CROSS JOIN LATERAL (SELECT DISTINCT unnest(b.topics) as topic) t
+		WHERE rh.id = ANY($1::uuid[])
+		GROUP BY rh.reader_id, t.topic
+		ON CONFLICT (user_id, interest)
+		DO UPDATE SET weight = LEAST($3, user_interests.weight + EXCLUDED.weight), last_signal_at = CURRENT_TIMESTAMP
+	`, pq.Array(ids), amount, MaxInterestWeight)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reading_history SET interest_signal_at = CURRENT_TIMESTAMP WHERE id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), tx.Commit()
+}
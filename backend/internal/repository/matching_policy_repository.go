@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type MatchingPolicyRepository struct {
+	db *sql.DB
+}
+
+func NewMatchingPolicyRepository(db *sql.DB) *MatchingPolicyRepository {
+	return &MatchingPolicyRepository{db: db}
+}
+
+const matchingPolicyColumns = `
+	id, version, success_weight, interest_weight, distance_weight, wait_weight,
+	distance_decay, distance_scale_km, missing_distance_km, wait_saturation_days,
+	aging_points_per_day, max_wins_per_period, win_period_days, tie_break,
+	COALESCE(notes, '') as notes, is_active, created_by, created_at
+`
+
+func scanMatchingPolicy(row interface{ Scan(...interface{}) error }) (*models.MatchingPolicy, error) {
+	p := &models.MatchingPolicy{}
+	err := row.Scan(&p.ID, &p.Version, &p.SuccessWeight, &p.InterestWeight, &p.DistanceWeight,
+		&p.WaitWeight, &p.DistanceDecay, &p.DistanceScaleKm, &p.MissingDistanceKm,
+		&p.WaitSaturationDays, &p.AgingPointsPerDay, &p.MaxWinsPerPeriod, &p.WinPeriodDays,
+		&p.TieBreak, &p.Notes, &p.IsActive, &p.CreatedBy, &p.CreatedAt)
+	return p, err
+}
+
+func (r *MatchingPolicyRepository) GetActive() (*models.MatchingPolicy, error) {
+	p, err := scanMatchingPolicy(r.db.QueryRow(`SELECT ` + matchingPolicyColumns + ` FROM matching_policies WHERE is_active`))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no active matching policy")
+	}
+	return p, err
+}
+
+func (r *MatchingPolicyRepository) GetByVersion(version int) (*models.MatchingPolicy, error) {
+	p, err := scanMatchingPolicy(r.db.QueryRow(`SELECT `+matchingPolicyColumns+` FROM matching_policies WHERE version = $1`, version))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("matching policy not found")
+	}
+	return p, err
+}
+
+func (r *MatchingPolicyRepository) List() ([]*models.MatchingPolicy, error) {
+	rows, err := r.db.Query(`SELECT ` + matchingPolicyColumns + ` FROM matching_policies ORDER BY version DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []*models.MatchingPolicy
+	for rows.Next() {
+		p, err := scanMatchingPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// Create stores a new policy version and makes it the active one.
+func (r *MatchingPolicyRepository) Create(p *models.MatchingPolicy) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE matching_policies SET is_active = false WHERE is_active`); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO matching_policies (success_weight, interest_weight, distance_weight, wait_weight,
+			distance_decay, distance_scale_km, missing_distance_km, wait_saturation_days,
+			aging_points_per_day, max_wins_per_period, win_period_days, tie_break, notes, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, true, $14)
+		RETURNING id, version, created_at
+	`, p.SuccessWeight, p.InterestWeight, p.DistanceWeight, p.WaitWeight, p.DistanceDecay,
+		p.DistanceScaleKm, p.MissingDistanceKm, p.WaitSaturationDays, p.AgingPointsPerDay,
+		p.MaxWinsPerPeriod, p.WinPeriodDays, p.TieBreak, p.Notes, p.CreatedBy).
+		Scan(&p.ID, &p.Version, &p.CreatedAt)
+	if err != nil {
+		return err
+	}
+	p.IsActive = true
+
+	return tx.Commit()
+}
+
+// Activate rolls the active policy back (or forward) to an existing version.
+func (r *MatchingPolicyRepository) Activate(version int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE matching_policies SET is_active = false WHERE is_active`); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`UPDATE matching_policies SET is_active = true WHERE version = $1`, version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("matching policy not found")
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+var (
+	ErrContentNotFound = fmt.Errorf("content not found")
+	ErrAlreadyReported = fmt.Errorf("you have already reported this content")
+	ErrNoOpenReports   = fmt.Errorf("there are no open reports for this content")
+)
+
+// reportTarget says where each reportable kind of content lives: its
+// table, who wrote it, its text, the column that hides it and when it
+// counts as already gone.
+type reportTarget struct {
+	table  string
+	author string
+	text   string
+	hidden string
+	gone   string
+}
+
+var reportTargets = map[models.ReportResourceType]reportTarget{
+	models.ReportIdea:     {"reading_ideas", "user_id", "title || E'\\n\\n' || content", "hidden_at", "deleted_at IS NOT NULL"},
+	models.ReportComment:  {"idea_comments", "user_id", "content", "hidden_at", "deleted_at IS NOT NULL"},
+	models.ReportReview:   {"user_reviews", "reviewer_id", "COALESCE(comment, '')", "hidden_at", "removed_at IS NOT NULL"},
+	models.ReportBio:      {"users", "id", "COALESCE(bio, '')", "bio_hidden_at", "COALESCE(bio, '') = ''"},
+	models.ReportDonation: {"donations", "donor_id", "COALESCE(message, '')", "hidden_at", "COALESCE(message, '') = ''"},
+}
+
+func target(resourceType models.ReportResourceType) (reportTarget, error) {
+	t, ok := reportTargets[resourceType]
+	if !ok {
+		return t, fmt.Errorf("unknown resource type: %s", resourceType)
+	}
+	return t, nil
+}
+
+// FindContent loads the reported content itself, without any report data.
+func (r *ReportRepository) FindContent(resourceType models.ReportResourceType, resourceID string) (*models.ReportedContent, error) {
+	t, err := target(resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &models.ReportedContent{ResourceType: resourceType, ResourceID: resourceID, Reasons: []string{}}
+	err = r.db.QueryRow(fmt.Sprintf(`
+		SELECT %s, %s, %s IS NOT NULL, %s FROM %s WHERE id = $1
+	`, t.author, t.text, t.hidden, t.gone, t.table), resourceID).
+		Scan(&content.AuthorID, &content.Text, &content.Hidden, &content.Deleted)
+	if err == sql.ErrNoRows {
+		return nil, ErrContentNotFound
+	}
+	return content, err
+}
+
+func (r *ReportRepository) Create(report *models.ContentReport) error {
+	err := r.db.QueryRow(`
+		INSERT INTO content_reports (resource_type, resource_id, author_id, reporter_id, reason, details)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING id, status, created_at
+	`, report.ResourceType, report.ResourceID, report.AuthorID, report.ReporterID, report.Reason, report.Details).
+		Scan(&report.ID, &report.Status, &report.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrAlreadyReported
+	}
+	return err
+}
+
+// CreateFilterFlag puts content the automatic filter flagged into the
+// moderation queue, unless it is already waiting there from an earlier flag.
+func (r *ReportRepository) CreateFilterFlag(resourceType models.ReportResourceType, resourceID, authorID, details string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO content_reports (resource_type, resource_id, author_id, reason, details)
+		SELECT $1, $2, $3, 'filter', $4
+		WHERE NOT EXISTS (
+			SELECT 1 FROM content_reports
+			WHERE resource_type = $1 AND resource_id = $2 AND reason = 'filter' AND status = 'open'
+		)
+	`, resourceType, resourceID, authorID, details)
+	return err
+}
+
+// CountOpen is the number of members with an open report on the content.
+func (r *ReportRepository) CountOpen(resourceType models.ReportResourceType, resourceID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM content_reports
+		WHERE resource_type = $1 AND resource_id = $2 AND status = 'open'
+	`, resourceType, resourceID).Scan(&n)
+	return n, err
+}
+
+// AutoHide hides content that reached the report threshold and reports
+// whether anything changed. The open reports remember that they hid it, so
+// dismissing them unhides it again; a hide a moderator chose is left alone.
+func (r *ReportRepository) AutoHide(resourceType models.ReportResourceType, resourceID string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	hidden, err := setHidden(tx, resourceType, resourceID, true)
+	if err != nil || !hidden {
+		return false, err
+	}
+	_, err = tx.Exec(`
+		UPDATE content_reports SET auto_hid = TRUE
+		WHERE resource_type = $1 AND resource_id = $2 AND status = 'open'
+	`, resourceType, resourceID)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func setHidden(db interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, resourceType models.ReportResourceType, resourceID string, hidden bool) (bool, error) {
+	t, err := target(resourceType)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET %s = CURRENT_TIMESTAMP WHERE id = $1 AND %s IS NULL`, t.table, t.hidden, t.hidden)
+	if !hidden {
+		query = fmt.Sprintf(`UPDATE %s SET %s = NULL WHERE id = $1 AND %s IS NOT NULL`, t.table, t.hidden, t.hidden)
+	}
+	res, err := db.Exec(query, resourceID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ClearTextTx deletes a bio or donation message, which unlike ideas, comments
+// and reviews have no soft delete of their own, inside the caller's
+// transaction.
+func (r *ReportRepository) ClearTextTx(tx *sql.Tx, resourceType models.ReportResourceType, resourceID string) error {
+	var query string
+	switch resourceType {
+	case models.ReportBio:
+		query = `UPDATE users SET bio = '', bio_hidden_at = NULL WHERE id = $1`
+	case models.ReportDonation:
+		query = `UPDATE donations SET message = '', hidden_at = NULL WHERE id = $1`
+	default:
+		return fmt.Errorf("cannot clear the text of a %s", resourceType)
+	}
+	_, err := tx.Exec(query, resourceID)
+	return err
+}
+
+// Queue lists content with open reports, most reported first and then
+// oldest first, so the worst and longest-waiting cases come up first.
+func (r *ReportRepository) Queue(limit, offset int) ([]*models.ReportedContent, error) {
+	rows, err := r.db.Query(`
+		SELECT resource_type, resource_id, COUNT(*), ARRAY_AGG(DISTINCT reason),
+		       MIN(created_at), MAX(created_at)
+		FROM content_reports
+		WHERE status = 'open'
+		GROUP BY resource_type, resource_id
+		ORDER BY COUNT(*) DESC, MIN(created_at) ASC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := []*models.ReportedContent{}
+	for rows.Next() {
+		item := &models.ReportedContent{}
+		err := rows.Scan(&item.ResourceType, &item.ResourceID, &item.ReportCount,
+			pq.Array(&item.Reasons), &item.FirstReportedAt, &item.LatestReportedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		queue = append(queue, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Content lives in a different table per type, so fill it in per item;
+	// a page of the queue is small.
+	for _, item := range queue {
+		content, err := r.FindContent(item.ResourceType, item.ResourceID)
+		if err == ErrContentNotFound {
+			item.Deleted = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		item.AuthorID, item.Text, item.Hidden, item.Deleted = content.AuthorID, content.Text, content.Hidden, content.Deleted
+	}
+	return queue, nil
+}
+
+// ListForContent returns every report filed against the content, newest
+// first.
+func (r *ReportRepository) ListForContent(resourceType models.ReportResourceType, resourceID string) ([]*models.ContentReport, error) {
+	rows, err := r.db.Query(`
+		SELECT id, resource_type, resource_id, author_id, COALESCE(reporter_id::text, ''), reason, COALESCE(details, ''),
+		       status, action, resolved_by, COALESCE(resolution_note, ''), created_at, resolved_at
+		FROM content_reports
+		WHERE resource_type = $1 AND resource_id = $2
+		ORDER BY created_at DESC
+	`, resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*models.ContentReport{}
+	for rows.Next() {
+		rep := &models.ContentReport{}
+		err := rows.Scan(&rep.ID, &rep.ResourceType, &rep.ResourceID, &rep.AuthorID, &rep.ReporterID,
+			&rep.Reason, &rep.Details, &rep.Status, &rep.Action, &rep.ResolvedBy, &rep.ResolutionNote,
+			&rep.CreatedAt, &rep.ResolvedAt)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+	return reports, rows.Err()
+}
+
+// Resolve closes every open report on the content with the moderator's
+// decision and returns the members who filed them. Hiding, counting a
+// warning against the author and running remove (which deletes the content
+// and reverses its score effect, and is only called for deletions) happen
+// in the same transaction, so a failure or a lost race leaves the content
+// and its reports as they were. Dismissing unhides the content only if
+// these reports hid it automatically.
+func (r *ReportRepository) Resolve(content *models.ReportedContent, action models.ModerationAction, moderatorID, note string, remove func(tx *sql.Tx) error) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, actionValue := models.ReportActioned, sql.NullString{String: string(action), Valid: true}
+	if action == models.ActionDismiss {
+		status, actionValue = models.ReportDismissed, sql.NullString{}
+	}
+
+	rows, err := tx.Query(`
+		UPDATE content_reports
+		SET status = $1, action = $2, resolved_by = $3, resolution_note = NULLIF($4, ''), resolved_at = CURRENT_TIMESTAMP
+		WHERE resource_type = $5 AND resource_id = $6 AND status = 'open'
+		RETURNING reporter_id, auto_hid
+	`, status, actionValue, moderatorID, note, content.ResourceType, content.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	reporters := []string{}
+	closed, autoHid := 0, false
+	for rows.Next() {
+		var id sql.NullString
+		var hid bool
+		if err := rows.Scan(&id, &hid); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		closed++
+		autoHid = autoHid || hid
+		if id.Valid {
+			reporters = append(reporters, id.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if closed == 0 {
+		return nil, ErrNoOpenReports
+	}
+
+	switch action {
+	case models.ActionHide:
+		_, err = setHidden(tx, content.ResourceType, content.ResourceID, true)
+	case models.ActionDismiss:
+		if autoHid {
+			_, err = setHidden(tx, content.ResourceType, content.ResourceID, false)
+		}
+	case models.ActionDelete:
+		if remove != nil {
+			err = remove(tx)
+		}
+	case models.ActionWarn:
+		_, err = tx.Exec(`UPDATE users SET warnings_received = warnings_received + 1 WHERE id = $1`, content.AuthorID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return reporters, tx.Commit()
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type RequestRepository struct {
+	db *sql.DB
+}
+
+func NewRequestRepository(db *sql.DB) *RequestRepository {
+	return &RequestRepository{db: db}
+}
+
+var (
+	ErrRequestNotFound  = fmt.Errorf("request not found")
+	ErrAlreadyRequested = fmt.Errorf("you have already requested this book")
+	ErrLoanNotFound     = fmt.Errorf("you are not borrowing this book")
+	ErrBookOnLoan       = fmt.Errorf("this book is still on loan and must be returned before it is handed over")
+)
+
+const requestColumns = `
+	id, book_id, user_id, status, priority_score, interest_match_score, distance_km,
+	success_component, interest_component, distance_component, wait_component, tier_component,
+	policy_version, scored_at, requested_at, processed_at, due_date, renewals, returned_at
+`
+
+func scanRequest(row interface{ Scan(...interface{}) error }) (*models.BookRequest, error) {
+	req := &models.BookRequest{}
+	err := row.Scan(&req.ID, &req.BookID, &req.UserID, &req.Status, &req.PriorityScore,
+		&req.InterestMatchScore, &req.DistanceKm, &req.SuccessComponent, &req.InterestComponent,
+		&req.DistanceComponent, &req.WaitComponent, &req.TierComponent, &req.PolicyVersion, &req.ScoredAt,
+		&req.RequestedAt, &req.ProcessedAt, &req.DueDate, &req.Renewals, &req.ReturnedAt)
+	return req, err
+}
+
+func (r *RequestRepository) FindByID(id string) (*models.BookRequest, error) {
+	req, err := scanRequest(r.db.QueryRow(`SELECT `+requestColumns+` FROM book_requests WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrRequestNotFound
+	}
+	return req, err
+}
+
+// Create files a pending request for a book. Its priority is filled in
+// when the book's requests are next rescored.
+func (r *RequestRepository) Create(req *models.BookRequest) error {
+	err := r.db.QueryRow(`
+		INSERT INTO book_requests (book_id, user_id)
+		VALUES ($1, $2)
+		RETURNING id, status, requested_at
+	`, req.BookID, req.UserID).Scan(&req.ID, &req.Status, &req.RequestedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrAlreadyRequested
+	}
+	return err
+}
+
+// FindLoan returns the member's unreturned approved request for a book.
+func (r *RequestRepository) FindLoan(bookID, userID string) (*models.BookRequest, error) {
+	req, err := scanRequest(r.db.QueryRow(`
+		SELECT `+requestColumns+` FROM book_requests
+		WHERE book_id = $1 AND user_id = $2 AND status = 'approved' AND returned_at IS NULL
+		ORDER BY processed_at DESC
+		LIMIT 1
+	`, bookID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrLoanNotFound
+	}
+	return req, err
+}
+
+// CountLoans returns how many books the member is borrowing now and how
+// many loans they have completed by returning the book.
+func (r *RequestRepository) CountLoans(userID string) (active, completed int, err error) {
+	err = r.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE returned_at IS NULL), COUNT(*) FILTER (WHERE returned_at IS NOT NULL)
+		FROM book_requests
+		WHERE user_id = $1 AND status = 'approved'
+	`, userID).Scan(&active, &completed)
+	return active, completed, err
+}
+
+// Approve hands the book to the requester: the request becomes a loan due
+// on dueDate, the requester becomes the holder and the handover is recorded
+// in the reading history. Returns ErrBookOnLoan while someone else's loan of
+// the book is still open.
+func (r *RequestRepository) Approve(req *models.BookRequest, giverID string, dueDate time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE book_requests
+		SET status = 'approved', processed_at = CURRENT_TIMESTAMP, due_date = $2
+		WHERE id = $1 AND status = 'pending'
+		RETURNING status, processed_at, due_date
+	`, req.ID, dueDate).Scan(&req.Status, &req.ProcessedAt, &req.DueDate)
+	if err == sql.ErrNoRows {
+		return ErrRequestNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// A borrower hands the book on only after returning it, so the return
+	// is scored as on time or late
+	var onLoan bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+		    SELECT 1 FROM book_requests
+		    WHERE book_id = $1 AND status = 'approved' AND returned_at IS NULL AND id <> $2
+		)
+	`, req.BookID, req.ID).Scan(&onLoan)
+	if err != nil {
+		return err
+	}
+	if onLoan {
+		return ErrBookOnLoan
+	}
+	_, err = tx.Exec(`
+		UPDATE reading_history
+		SET end_date = CURRENT_TIMESTAMP, duration_days = EXTRACT(DAY FROM CURRENT_TIMESTAMP - start_date)
+		WHERE book_id = $1 AND end_date IS NULL
+	`, req.BookID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE books SET current_holder_id = $2, status = 'reading', total_reads = total_reads + 1 WHERE id = $1
+	`, req.BookID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO reading_history (book_id, reader_id) VALUES ($1, $2)`, req.BookID, req.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE users SET books_received = books_received + 1 WHERE id = $1`, req.UserID); err != nil {
+		return err
+	}
+	if giverID != "" && giverID != req.UserID {
+		if _, err := tx.Exec(`UPDATE users SET books_shared = books_shared + 1 WHERE id = $1`, giverID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *RequestRepository) Reject(req *models.BookRequest) error {
+	err := r.db.QueryRow(`
+		UPDATE book_requests
+		SET status = 'rejected', processed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING status, processed_at
+	`, req.ID).Scan(&req.Status, &req.ProcessedAt)
+	if err == sql.ErrNoRows {
+		return ErrRequestNotFound
+	}
+	return err
+}
+
+// Return ends a loan. The reader keeps the book until it is handed to the
+// next requester, but it shows as available again.
+func (r *RequestRepository) Return(loan *models.BookRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE book_requests SET returned_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND returned_at IS NULL
+		RETURNING returned_at
+	`, loan.ID).Scan(&loan.ReturnedAt)
+	if err == sql.ErrNoRows {
+		return ErrLoanNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reading_history
+		SET end_date = CURRENT_TIMESTAMP, duration_days = EXTRACT(DAY FROM CURRENT_TIMESTAMP - start_date)
+		WHERE book_id = $1 AND reader_id = $2 AND end_date IS NULL
+	`, loan.BookID, loan.UserID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE books SET status = 'available' WHERE id = $1`, loan.BookID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Renew moves a loan's due date and counts the renewal.
+func (r *RequestRepository) Renew(loan *models.BookRequest, dueDate time.Time) error {
+	err := r.db.QueryRow(`
+		UPDATE book_requests SET due_date = $2, renewals = renewals + 1
+		WHERE id = $1 AND returned_at IS NULL
+		RETURNING due_date, renewals
+	`, loan.ID, dueDate).Scan(&loan.DueDate, &loan.Renewals)
+	if err == sql.ErrNoRows {
+		return ErrLoanNotFound
+	}
+	return err
+}
+
+// CountPending returns how many members are waiting for a book.
+func (r *RequestRepository) CountPending(bookID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM book_requests WHERE book_id = $1 AND status = 'pending'`, bookID).Scan(&n)
+	return n, err
+}
+
+// ListPending returns the pending requests for a book by priority score,
+// with the requester's public details attached. MatchingService.RankPending
+// gives the allocation order.
+func (r *RequestRepository) ListPending(bookID string) ([]*models.BookRequest, error) {
+	rows, err := r.db.Query(`
+		SELECT br.id, br.book_id, br.user_id, br.status, br.priority_score, br.interest_match_score,
+		       br.distance_km, br.requested_at,
+		       u.username, COALESCE(u.full_name, ''), COALESCE(u.avatar_url, ''), u.success_score,
+		       COALESCE(u.location_privacy, 'district')
+		FROM book_requests br
+		JOIN users u ON u.id = br.user_id
+		WHERE br.book_id = $1 AND br.status = 'pending'
+		ORDER BY br.priority_score DESC, br.requested_at ASC
+	`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*models.BookRequest{}
+	for rows.Next() {
+		req := &models.BookRequest{User: &models.User{}}
+		err := rows.Scan(&req.ID, &req.BookID, &req.UserID, &req.Status, &req.PriorityScore,
+			&req.InterestMatchScore, &req.DistanceKm, &req.RequestedAt,
+			&req.User.Username, &req.User.FullName, &req.User.AvatarURL, &req.User.SuccessScore,
+			&req.User.LocationPrivacy)
+		if err != nil {
+			return nil, err
+		}
+		req.User.ID = req.UserID
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type ReviewDisputeRepository struct {
+	db *sql.DB
+}
+
+func NewReviewDisputeRepository(db *sql.DB) *ReviewDisputeRepository {
+	return &ReviewDisputeRepository{db: db}
+}
+
+var (
+	ErrAlreadyDisputed = fmt.Errorf("this review has already been disputed")
+	ErrDisputeResolved = fmt.Errorf("dispute has already been resolved")
+)
+
+func (r *ReviewDisputeRepository) Create(d *models.ReviewDispute) error {
+	err := r.db.QueryRow(`
+		INSERT INTO review_disputes (review_id, disputed_by, statement)
+		VALUES ($1, $2, $3)
+		RETURNING id, status, created_at
+	`, d.ReviewID, d.DisputedBy, d.Statement).Scan(&d.ID, &d.Status, &d.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrAlreadyDisputed
+	}
+	return err
+}
+
+const disputeSelect = `
+	SELECT d.id, d.review_id, d.disputed_by, d.statement, d.status, d.resolved_by,
+	       COALESCE(d.resolution_note, ''), d.created_at, d.resolved_at,
+	       r.reviewer_id, r.reviewee_id, r.book_id, r.behavior_rating, r.book_condition_rating,
+	       r.communication_rating, COALESCE(r.comment, ''), r.exchange_id, r.created_at, r.updated_at
+	FROM review_disputes d
+	JOIN user_reviews r ON r.id = d.review_id
+`
+
+func scanDispute(row interface{ Scan(...interface{}) error }) (*models.ReviewDispute, error) {
+	d := &models.ReviewDispute{Review: &models.UserReview{}}
+	err := row.Scan(&d.ID, &d.ReviewID, &d.DisputedBy, &d.Statement, &d.Status, &d.ResolvedBy,
+		&d.ResolutionNote, &d.CreatedAt, &d.ResolvedAt,
+		&d.Review.ReviewerID, &d.Review.RevieweeID, &d.Review.BookID, &d.Review.BehaviorRating,
+		&d.Review.BookConditionRating, &d.Review.CommunicationRating, &d.Review.Comment,
+		&d.Review.ExchangeID, &d.Review.CreatedAt, &d.Review.UpdatedAt)
+	d.Review.ID = d.ReviewID
+	return d, err
+}
+
+func (r *ReviewDisputeRepository) FindByID(id string) (*models.ReviewDispute, error) {
+	d, err := scanDispute(r.db.QueryRow(disputeSelect+` WHERE d.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("dispute not found")
+	}
+	return d, err
+}
+
+// List returns disputes with the given status (all when empty), oldest
+// first so the queue is worked in order.
+func (r *ReviewDisputeRepository) List(status string, limit, offset int) ([]*models.ReviewDispute, error) {
+	rows, err := r.db.Query(disputeSelect+`
+		WHERE $1 = '' OR d.status = $1
+		ORDER BY d.created_at ASC
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	disputes := []*models.ReviewDispute{}
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, d)
+	}
+	return disputes, rows.Err()
+}
+
+// Resolve closes an open dispute. Removing the review hides it, takes it out
+// of the reviewee's review count and runs reverse (which undoes its score
+// effect) in the same transaction, so a failure leaves the dispute open to
+// be resolved again.
+func (r *ReviewDisputeRepository) Resolve(d *models.ReviewDispute, status models.DisputeStatus, adminID, note string, reverse func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE review_disputes
+		SET status = $1, resolved_by = $2, resolution_note = $3, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status = 'open'
+		RETURNING status, resolved_by, resolution_note, resolved_at
+	`, status, adminID, note, d.ID).Scan(&d.Status, &d.ResolvedBy, &d.ResolutionNote, &d.ResolvedAt)
+	if err == sql.ErrNoRows {
+		return ErrDisputeResolved
+	}
+	if err != nil {
+		return err
+	}
+
+	if status == models.DisputeRemoved {
+		_, err = tx.Exec(`UPDATE user_reviews SET removed_at = CURRENT_TIMESTAMP WHERE id = $1`, d.ReviewID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			UPDATE users SET reviews_received = GREATEST(reviews_received - 1, 0) WHERE id = $1
+		`, d.Review.RevieweeID)
+		if err != nil {
+			return err
+		}
+		if err := reverse(tx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/yourusername/online-library/internal/models"
 )
 
@@ -14,23 +17,111 @@ func NewReviewRepository(db *sql.DB) *ReviewRepository {
 	return &ReviewRepository{db: db}
 }
 
+var ErrDuplicateReview = fmt.Errorf("you have already reviewed this exchange")
+
+// exchangeSelect resolves handovers from reading_history: each entry is the
+// receiver's side, and the giver is whoever read the book before them, or
+// whoever listed it for the first handover.
+const exchangeSelect = `
+	SELECT rh.id, rh.book_id, b.title, COALESCE(prev.reader_id, b.created_by), rh.reader_id, rh.start_date
+	FROM reading_history rh
+	JOIN books b ON b.id = rh.book_id
+	LEFT JOIN LATERAL (
+		SELECT p.reader_id FROM reading_history p
+		WHERE p.book_id = rh.book_id AND p.start_date < rh.start_date
+		ORDER BY p.start_date DESC
+		LIMIT 1
+	) prev ON true
+`
+
+// Create stores the review and bumps the reviewee's review count together.
+// A second review of the same exchange by the same reviewer returns
+// ErrDuplicateReview.
 func (r *ReviewRepository) Create(review *models.UserReview) error {
-	return r.db.QueryRow(`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
 		INSERT INTO user_reviews (reviewer_id, reviewee_id, book_id, behavior_rating, 
-			book_condition_rating, communication_rating, comment)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at
+			book_condition_rating, communication_rating, comment, exchange_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
 	`, review.ReviewerID, review.RevieweeID, review.BookID, review.BehaviorRating,
-		review.BookConditionRating, review.CommunicationRating, review.Comment).
-		Scan(&review.ID, &review.CreatedAt)
+		review.BookConditionRating, review.CommunicationRating, review.Comment, review.ExchangeID).
+		Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicateReview
+		}
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET reviews_received = reviews_received + 1 WHERE id = $1
+	`, review.RevieweeID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ReviewRepository) FindByID(id string) (*models.UserReview, error) {
+	review := &models.UserReview{}
+	err := r.db.QueryRow(`
+		SELECT id, reviewer_id, reviewee_id, book_id, behavior_rating, 
+			book_condition_rating, communication_rating, COALESCE(comment, ''), exchange_id,
+			removed_at, created_at, updated_at
+		FROM user_reviews
+		WHERE id = $1
+	`, id).Scan(&review.ID, &review.ReviewerID, &review.RevieweeID, &review.BookID,
+		&review.BehaviorRating, &review.BookConditionRating, &review.CommunicationRating,
+		&review.Comment, &review.ExchangeID, &review.RemovedAt, &review.CreatedAt, &review.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("review not found")
+	}
+	return review, err
+}
+
+func (r *ReviewRepository) Update(review *models.UserReview) error {
+	return r.db.QueryRow(`
+		UPDATE user_reviews
+		SET behavior_rating = $1, book_condition_rating = $2, communication_rating = $3, comment = $4
+		WHERE id = $5
+		RETURNING updated_at
+	`, review.BehaviorRating, review.BookConditionRating, review.CommunicationRating,
+		review.Comment, review.ID).Scan(&review.UpdatedAt)
+}
+
+// RemoveTx takes a review down for good and out of the reviewee's review
+// count, inside the caller's transaction.
+func (r *ReviewRepository) RemoveTx(tx *sql.Tx, review *models.UserReview) error {
+	res, err := tx.Exec(`
+		UPDATE user_reviews SET removed_at = CURRENT_TIMESTAMP WHERE id = $1 AND removed_at IS NULL
+	`, review.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("review not found")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET reviews_received = GREATEST(reviews_received - 1, 0) WHERE id = $1
+	`, review.RevieweeID)
+	return err
 }
 
 func (r *ReviewRepository) GetByReviewee(revieweeID string, limit, offset int) ([]*models.UserReview, error) {
 	rows, err := r.db.Query(`
 		SELECT id, reviewer_id, reviewee_id, book_id, behavior_rating, 
-			book_condition_rating, communication_rating, comment, created_at
+			book_condition_rating, communication_rating, COALESCE(comment, ''), exchange_id,
+			created_at, updated_at
 		FROM user_reviews
-		WHERE reviewee_id = $1
+		WHERE reviewee_id = $1 AND removed_at IS NULL AND hidden_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`, revieweeID, limit, offset)
@@ -44,7 +135,7 @@ func (r *ReviewRepository) GetByReviewee(revieweeID string, limit, offset int) (
 		review := &models.UserReview{}
 		err := rows.Scan(&review.ID, &review.ReviewerID, &review.RevieweeID, &review.BookID,
 			&review.BehaviorRating, &review.BookConditionRating, &review.CommunicationRating,
-			&review.Comment, &review.CreatedAt)
+			&review.Comment, &review.ExchangeID, &review.CreatedAt, &review.UpdatedAt)
 		if err != nil {
 			continue
 		}
@@ -53,24 +144,107 @@ func (r *ReviewRepository) GetByReviewee(revieweeID string, limit, offset int) (
 	return reviews, nil
 }
 
-func (r *ReviewRepository) GetAverageRatings(revieweeID string) (map[string]float64, error) {
-	var behaviorAvg, conditionAvg, communicationAvg sql.NullFloat64
-	err := r.db.QueryRow(`
-		SELECT 
-			AVG(behavior_rating) as behavior_avg,
-			AVG(book_condition_rating) as condition_avg,
-			AVG(communication_rating) as communication_avg
-		FROM user_reviews
-		WHERE reviewee_id = $1
-	`, revieweeID).Scan(&behaviorAvg, &conditionAvg, &communicationAvg)
+func (r *ReviewRepository) FindExchange(id string) (*models.Exchange, error) {
+	e := &models.Exchange{}
+	var giverID sql.NullString
+	err := r.db.QueryRow(exchangeSelect+` WHERE rh.id = $1`, id).
+		Scan(&e.ID, &e.BookID, &e.BookTitle, &giverID, &e.ReceiverID, &e.HandedOverAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("exchange not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.GiverID = giverID.String
+	return e, nil
+}
+
+// ListReviewable returns exchanges since the given time that userID took part
+// in and hasn't reviewed yet.
+func (r *ReviewRepository) ListReviewable(userID string, since time.Time) ([]*models.Exchange, error) {
+	rows, err := r.db.Query(`
+		SELECT * FROM (`+exchangeSelect+`
+			WHERE rh.start_date >= $2
+		) e(id, book_id, book_title, giver_id, receiver_id, handed_over_at)
+		WHERE ($1 IN (giver_id, receiver_id)) AND giver_id IS NOT NULL AND giver_id <> receiver_id
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_reviews ur WHERE ur.exchange_id = e.id AND ur.reviewer_id = $1
+		  )
+		ORDER BY handed_over_at DESC
+	`, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exchanges := []*models.Exchange{}
+	for rows.Next() {
+		e := &models.Exchange{}
+		if err := rows.Scan(&e.ID, &e.BookID, &e.BookTitle, &e.GiverID, &e.ReceiverID, &e.HandedOverAt); err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges, rows.Err()
+}
 
+// reviewOverallSQL is one review's overall rating: the mean of whichever
+// dimensions the reviewer filled in.
+const reviewOverallSQL = `
+	(COALESCE(behavior_rating, 0) + COALESCE(book_condition_rating, 0) + COALESCE(communication_rating, 0))::float8
+	/ NULLIF((behavior_rating IS NOT NULL)::int + (book_condition_rating IS NOT NULL)::int + (communication_rating IS NOT NULL)::int, 0)
+`
+
+// GetStats aggregates the reviews received by each user. Reviews posted at or
+// after recentSince are also averaged separately so a trend can be derived.
+func (r *ReviewRepository) GetStats(userIDs []string, recentSince time.Time) (map[string]*models.ReviewStats, error) {
+	rows, err := r.db.Query(`
+		WITH r AS (
+			SELECT reviewee_id, behavior_rating, book_condition_rating, communication_rating,
+			       created_at, `+reviewOverallSQL+` as overall
+			FROM user_reviews
+			WHERE reviewee_id = ANY($1::uuid[]) AND removed_at IS NULL AND hidden_at IS NULL
+		)
+		SELECT reviewee_id, COUNT(*),
+		       AVG(behavior_rating), COUNT(behavior_rating),
+		       AVG(book_condition_rating), COUNT(book_condition_rating),
+		       AVG(communication_rating), COUNT(communication_rating),
+		       COUNT(overall), COALESCE(SUM(overall), 0),
+		       AVG(overall) FILTER (WHERE created_at >= $2), COUNT(overall) FILTER (WHERE created_at >= $2),
+		       AVG(overall) FILTER (WHERE created_at < $2), COUNT(overall) FILTER (WHERE created_at < $2)
+		FROM r
+		GROUP BY reviewee_id
+	`, pq.Array(userIDs), recentSince)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	stats := make(map[string]*models.ReviewStats, len(userIDs))
+	for _, id := range userIDs {
+		stats[id] = &models.ReviewStats{UserID: id}
+	}
+	for rows.Next() {
+		s := &models.ReviewStats{}
+		err := rows.Scan(&s.UserID, &s.ReviewCount,
+			&s.BehaviorAvg, &s.BehaviorCount,
+			&s.ConditionAvg, &s.ConditionCount,
+			&s.CommunicationAvg, &s.CommunicationCount,
+			&s.RatedCount, &s.RatingSum,
+			&s.RecentAvg, &s.RecentCount,
+			&s.EarlierAvg, &s.EarlierCount)
+		if err != nil {
+			return nil, err
+		}
+		stats[s.UserID] = s
+	}
+	return stats, rows.Err()
+}
 
-	return map[string]float64{
-		"behavior":      behaviorAvg.Float64,
-		"condition":     conditionAvg.Float64,
-		"communication": communicationAvg.Float64,
-	}, nil
+// GetGlobalAverage is the mean overall rating across every review, used as
+// the prior when smoothing individual ratings.
+func (r *ReviewRepository) GetGlobalAverage() (sql.NullFloat64, error) {
+	var avg sql.NullFloat64
+	err := r.db.QueryRow(`SELECT AVG(` + reviewOverallSQL + `) FROM user_reviews WHERE removed_at IS NULL AND hidden_at IS NULL`).Scan(&avg)
+	return avg, err
 }
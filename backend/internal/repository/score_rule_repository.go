@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type ScoreRuleRepository struct {
+	db *sql.DB
+}
+
+func NewScoreRuleRepository(db *sql.DB) *ScoreRuleRepository {
+	return &ScoreRuleRepository{db: db}
+}
+
+var ErrScoreRulesNotFound = fmt.Errorf("score rule set not found")
+
+const scoreRuleSetColumns = `
+	id, version, starting_score, min_request_score, COALESCE(notes, '') as notes,
+	is_active, created_by, created_at
+`
+
+func scanScoreRuleSet(row interface{ Scan(...interface{}) error }) (*models.ScoreRuleSet, error) {
+	rs := &models.ScoreRuleSet{Rules: []models.ScoreRule{}, Tiers: []models.ScoreTier{}}
+	err := row.Scan(&rs.ID, &rs.Version, &rs.StartingScore, &rs.MinRequestScore, &rs.Notes,
+		&rs.IsActive, &rs.CreatedBy, &rs.CreatedAt)
+	return rs, err
+}
+
+// loadRules fills in the rules and tiers of each rule set.
+func (r *ScoreRuleRepository) loadRules(sets ...*models.ScoreRuleSet) error {
+	for _, rs := range sets {
+		rows, err := r.db.Query(`
+			SELECT event_type, delta, period_cap, cap_period_days
+			FROM score_rules WHERE rule_set_id = $1
+			ORDER BY event_type
+		`, rs.ID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var rule models.ScoreRule
+			if err := rows.Scan(&rule.EventType, &rule.Delta, &rule.PeriodCap, &rule.CapPeriodDays); err != nil {
+				rows.Close()
+				return err
+			}
+			rs.Rules = append(rs.Rules, rule)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		rows, err = r.db.Query(`
+			SELECT level, name, min_score, min_completed_loans, max_active_loans,
+			       max_loan_days, max_renewals, priority_bonus
+			FROM score_tiers WHERE rule_set_id = $1
+			ORDER BY level
+		`, rs.ID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var t models.ScoreTier
+			err := rows.Scan(&t.Level, &t.Name, &t.MinScore, &t.MinCompletedLoans, &t.MaxActiveLoans,
+				&t.MaxLoanDays, &t.MaxRenewals, &t.PriorityBonus)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			rs.Tiers = append(rs.Tiers, t)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ScoreRuleRepository) GetActive() (*models.ScoreRuleSet, error) {
+	rs, err := scanScoreRuleSet(r.db.QueryRow(`SELECT ` + scoreRuleSetColumns + ` FROM score_rule_sets WHERE is_active`))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no active score rule set")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rs, r.loadRules(rs)
+}
+
+func (r *ScoreRuleRepository) GetByVersion(version int) (*models.ScoreRuleSet, error) {
+	rs, err := scanScoreRuleSet(r.db.QueryRow(`SELECT `+scoreRuleSetColumns+` FROM score_rule_sets WHERE version = $1`, version))
+	if err == sql.ErrNoRows {
+		return nil, ErrScoreRulesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rs, r.loadRules(rs)
+}
+
+func (r *ScoreRuleRepository) List() ([]*models.ScoreRuleSet, error) {
+	rows, err := r.db.Query(`SELECT ` + scoreRuleSetColumns + ` FROM score_rule_sets ORDER BY version DESC`)
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []*models.ScoreRuleSet
+	for rows.Next() {
+		rs, err := scanScoreRuleSet(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sets = append(sets, rs)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sets, r.loadRules(sets...)
+}
+
+// Create stores a new rule set version and makes it the active one.
+func (r *ScoreRuleRepository) Create(rs *models.ScoreRuleSet) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE score_rule_sets SET is_active = false WHERE is_active`); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO score_rule_sets (starting_score, min_request_score, notes, is_active, created_by)
+		VALUES ($1, $2, $3, true, $4)
+		RETURNING id, version, created_at
+	`, rs.StartingScore, rs.MinRequestScore, rs.Notes, rs.CreatedBy).
+		Scan(&rs.ID, &rs.Version, &rs.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rs.Rules {
+		_, err := tx.Exec(`
+			INSERT INTO score_rules (rule_set_id, event_type, delta, period_cap, cap_period_days)
+			VALUES ($1, $2, $3, $4, $5)
+		`, rs.ID, rule.EventType, rule.Delta, rule.PeriodCap, rule.CapPeriodDays)
+		if err != nil {
+			return err
+		}
+	}
+	for _, t := range rs.Tiers {
+		_, err := tx.Exec(`
+			INSERT INTO score_tiers (rule_set_id, level, name, min_score, min_completed_loans,
+				max_active_loans, max_loan_days, max_renewals, priority_bonus)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, rs.ID, t.Level, t.Name, t.MinScore, t.MinCompletedLoans, t.MaxActiveLoans,
+			t.MaxLoanDays, t.MaxRenewals, t.PriorityBonus)
+		if err != nil {
+			return err
+		}
+	}
+	rs.IsActive = true
+
+	return tx.Commit()
+}
+
+// Activate rolls the active rule set back (or forward) to an existing version.
+func (r *ScoreRuleRepository) Activate(version int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE score_rule_sets SET is_active = false WHERE is_active`); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`UPDATE score_rule_sets SET is_active = true WHERE version = $1`, version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrScoreRulesNotFound
+	}
+
+	return tx.Commit()
+}
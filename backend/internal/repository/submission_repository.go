@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/contentfilter"
+)
+
+// SubmissionRepository keeps the log of accepted posts that the content
+// filter's duplicate and rate limit checks look back over. It implements
+// contentfilter.History.
+type SubmissionRepository struct {
+	db *sql.DB
+}
+
+func NewSubmissionRepository(db *sql.DB) *SubmissionRepository {
+	return &SubmissionRepository{db: db}
+}
+
+func (r *SubmissionRepository) Record(post contentfilter.Post, resourceID string, result contentfilter.Result) error {
+	_, err := r.db.Exec(`
+		INSERT INTO content_submissions (user_id, kind, resource_id, fingerprint, verdict, reasons)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, ''), $5, $6)
+	`, post.UserID, post.Kind, resourceID, contentfilter.Fingerprint(post.Text), result.Verdict, pq.Array(result.Reasons))
+	return err
+}
+
+func (r *SubmissionRepository) CountPosts(userID, kind string, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM content_submissions
+		WHERE user_id = $1 AND kind = $2 AND created_at >= $3
+	`, userID, kind, since).Scan(&n)
+	return n, err
+}
+
+func (r *SubmissionRepository) CountDuplicates(userID, fingerprint, excludeID string, since time.Time) (int, int, error) {
+	var own, all int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE user_id = $1), COUNT(*)
+		FROM content_submissions
+		WHERE fingerprint = $2 AND created_at >= $3
+		  AND ($4 = '' OR resource_id IS DISTINCT FROM NULLIF($4, '')::uuid)
+	`, userID, fingerprint, since, excludeID).Scan(&own, &all)
+	return own, all, err
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/models"
+)
+
+type TaxonomyRepository struct {
+	db *sql.DB
+}
+
+func NewTaxonomyRepository(db *sql.DB) *TaxonomyRepository {
+	return &TaxonomyRepository{db: db}
+}
+
+var (
+	ErrTermExists = fmt.Errorf("term already exists")
+	ErrAliasTaken = fmt.Errorf("alias is already a term or another term's alias")
+)
+
+// normalizedSQL mirrors models.NormalizeTerm for values already stored in
+// books and user_interests.
+const normalizedSQL = `lower(regexp_replace(btrim(%s), '\s+', ' ', 'g'))`
+
+func (r *TaxonomyRepository) List(kind string) ([]*models.TaxonomyTerm, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.kind, t.name, t.parent_id, t.created_at, t.updated_at,
+		       COALESCE(array_agg(a.alias ORDER BY a.alias) FILTER (WHERE a.id IS NOT NULL), '{}')
+		FROM taxonomy_terms t
+		LEFT JOIN taxonomy_aliases a ON a.term_id = t.id
+		WHERE $1 = '' OR t.kind = $1
+		GROUP BY t.id
+		ORDER BY t.kind, t.name
+	`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := []*models.TaxonomyTerm{}
+	for rows.Next() {
+		t := &models.TaxonomyTerm{}
+		if err := rows.Scan(&t.ID, &t.Kind, &t.Name, &t.ParentID, &t.CreatedAt, &t.UpdatedAt,
+			pq.Array(&t.Aliases)); err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	return terms, rows.Err()
+}
+
+func (r *TaxonomyRepository) FindByID(id string) (*models.TaxonomyTerm, error) {
+	t := &models.TaxonomyTerm{}
+	err := r.db.QueryRow(`
+		SELECT t.id, t.kind, t.name, t.parent_id, t.created_at, t.updated_at,
+		       COALESCE(array_agg(a.alias ORDER BY a.alias) FILTER (WHERE a.id IS NOT NULL), '{}')
+		FROM taxonomy_terms t
+		LEFT JOIN taxonomy_aliases a ON a.term_id = t.id
+		WHERE t.id = $1
+		GROUP BY t.id
+	`, id).Scan(&t.ID, &t.Kind, &t.Name, &t.ParentID, &t.CreatedAt, &t.UpdatedAt, pq.Array(&t.Aliases))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("term not found")
+	}
+	return t, err
+}
+
+func (r *TaxonomyRepository) Create(t *models.TaxonomyTerm) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO taxonomy_terms (kind, name, normalized, parent_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, t.Kind, t.Name, models.NormalizeTerm(t.Name), t.ParentID).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrTermExists
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range t.Aliases {
+		if err := addAlias(tx, t.ID, t.Kind, alias); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Update renames and/or reparents a term. A rename keeps the old name as an
+// alias and rewrites books and interests that used it.
+func (r *TaxonomyRepository) Update(t *models.TaxonomyTerm, oldName string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE taxonomy_terms SET name = $1, normalized = $2, parent_id = $3 WHERE id = $4
+	`, t.Name, models.NormalizeTerm(t.Name), t.ParentID, t.ID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrTermExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if models.NormalizeTerm(oldName) != models.NormalizeTerm(t.Name) {
+		if err := addAlias(tx, t.ID, t.Kind, oldName); err != nil {
+			return err
+		}
+	}
+	if oldName != t.Name {
+		if err := rewriteTerm(tx, t.Kind, []string{models.NormalizeTerm(oldName)}, t.Name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *TaxonomyRepository) Delete(id string) error {
+	_, err := r.db.Exec(`DELETE FROM taxonomy_terms WHERE id = $1`, id)
+	return err
+}
+
+func (r *TaxonomyRepository) AddAlias(termID string, kind models.TermKind, alias string) error {
+	return addAlias(r.db, termID, kind, alias)
+}
+
+func (r *TaxonomyRepository) DeleteAlias(termID, alias string) error {
+	res, err := r.db.Exec(`
+		DELETE FROM taxonomy_aliases WHERE term_id = $1 AND normalized = $2
+	`, termID, models.NormalizeTerm(alias))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("alias not found")
+	}
+	return nil
+}
+
+// Merge folds source into target: source's name and aliases become aliases
+// of target, its children move under target, and every book and user
+// interest using any of source's forms is rewritten to target's name.
+func (r *TaxonomyRepository) Merge(source, target *models.TaxonomyTerm) error {
+	if source.Kind != target.Kind {
+		return fmt.Errorf("cannot merge a %s into a %s", source.Kind, target.Kind)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	forms := []string{models.NormalizeTerm(source.Name)}
+	for _, alias := range source.Aliases {
+		forms = append(forms, models.NormalizeTerm(alias))
+	}
+
+	if _, err := tx.Exec(`UPDATE taxonomy_terms SET parent_id = $1 WHERE parent_id = $2`, target.ID, source.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM taxonomy_terms WHERE id = $1`, source.ID); err != nil {
+		return err
+	}
+	if err := addAlias(tx, target.ID, target.Kind, source.Name); err != nil {
+		return err
+	}
+	for _, alias := range source.Aliases {
+		if err := addAlias(tx, target.ID, target.Kind, alias); err != nil {
+			return err
+		}
+	}
+
+	if err := rewriteTerm(tx, target.Kind, forms, target.Name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Canonicalize maps each value to its canonical term name. Values that match
+// no term or alias are returned trimmed but otherwise unchanged. Duplicates
+// (after canonicalization) are dropped, keeping the first occurrence.
+func (r *TaxonomyRepository) Canonicalize(kind models.TermKind, values []string) ([]string, error) {
+	var raw, normalized []string
+	for _, v := range values {
+		if n := models.NormalizeTerm(v); n != "" {
+			raw = append(raw, strings.Join(strings.Fields(v), " "))
+			normalized = append(normalized, n)
+		}
+	}
+	if len(raw) == 0 {
+		return []string{}, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT COALESCE(t.name, at.name, v.raw)
+		FROM unnest($2::text[], $3::text[]) WITH ORDINALITY AS v(raw, norm, ord)
+		LEFT JOIN taxonomy_terms t ON t.kind = $1 AND t.normalized = v.norm
+		LEFT JOIN taxonomy_aliases a ON a.kind = $1 AND a.normalized = v.norm
+		LEFT JOIN taxonomy_terms at ON at.id = a.term_id
+		ORDER BY v.ord
+	`, kind, pq.Array(raw), pq.Array(normalized))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	out := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if key := models.NormalizeTerm(name); !seen[key] {
+			seen[key] = true
+			out = append(out, name)
+		}
+	}
+	return out, rows.Err()
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// addAlias gives a term another spelling. Adding an alias the term already
+// has is a no-op; one that names a term or belongs to another term returns
+// ErrAliasTaken.
+func addAlias(db execer, termID string, kind models.TermKind, alias string) error {
+	normalized := models.NormalizeTerm(alias)
+	if normalized == "" {
+		return nil
+	}
+	res, err := db.Exec(`
+		INSERT INTO taxonomy_aliases (term_id, kind, alias, normalized)
+		SELECT $1, $2, $3, $4
+		WHERE NOT EXISTS (SELECT 1 FROM taxonomy_terms WHERE kind = $2 AND normalized = $4)
+		ON CONFLICT (kind, normalized) DO UPDATE SET term_id = $1
+		WHERE taxonomy_aliases.term_id = $1
+	`, termID, kind, strings.Join(strings.Fields(alias), " "), normalized)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAliasTaken
+	}
+	return nil
+}
+
+// rewriteTerm replaces every stored value whose normalized form is in forms
+// with name, in books and (for topics) user interests.
+func rewriteTerm(tx *sql.Tx, kind models.TermKind, forms []string, name string) error {
+	switch kind {
+	case models.TermCategory:
+		_, err := tx.Exec(`UPDATE books SET category = $1 WHERE `+fmt.Sprintf(normalizedSQL, "category")+` = ANY($2)`,
+			name, pq.Array(forms))
+		return err
+	case models.TermTag:
+		return rewriteBookArray(tx, "tags", forms, name)
+	case models.TermTopic:
+		if err := rewriteBookArray(tx, "topics", forms, name); err != nil {
+			return err
+		}
+		return rewriteInterests(tx, forms, name)
+	}
+	return fmt.Errorf("unknown term kind: %s", kind)
+}
+
+// rewriteBookArray rewrites matching elements of books.tags or books.topics,
+// dropping duplicates while keeping the original order.
+func rewriteBookArray(tx *sql.Tx, column string, forms []string, name string) error {
+	elem := fmt.Sprintf(normalizedSQL, "e")
+	query := fmt.Sprintf(`
+		UPDATE books SET %[1]s = ARRAY(
+			SELECT x FROM (
+				SELECT CASE WHEN %[2]s = ANY($2) THEN $1 ELSE e END as x, MIN(o) as o
+				FROM unnest(%[1]s) WITH ORDINALITY AS u(e, o)
+				GROUP BY 1
+			) s ORDER BY o
+		)
+		WHERE EXISTS (SELECT 1 FROM unnest(%[1]s) AS e WHERE %[2]s = ANY($2))
+	`, column, elem)
+	_, err := tx.Exec(query, name, pq.Array(forms))
+	return err
+}
+
+// rewriteInterests moves user interests onto the canonical topic, keeping the
+// strongest weight when a member had several variants.
+func rewriteInterests(tx *sql.Tx, forms []string, name string) error {
+	match := fmt.Sprintf(normalizedSQL, "interest") + ` = ANY($2)`
+	_, err := tx.Exec(`
+		INSERT INTO user_interests (user_id, interest, weight, explicit_weight, last_signal_at)
+		SELECT user_id, $1, MAX(weight), MAX(explicit_weight), MAX(last_signal_at)
+		FROM user_interests
+		WHERE `+match+`
+		GROUP BY user_id
+		ON CONFLICT (user_id, interest) DO UPDATE
+		SET weight = GREATEST(user_interests.weight, EXCLUDED.weight),
+		    explicit_weight = GREATEST(user_interests.explicit_weight, EXCLUDED.explicit_weight),
+		    last_signal_at = GREATEST(user_interests.last_signal_at, EXCLUDED.last_signal_at)
+	`, name, pq.Array(forms))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM user_interests WHERE `+match+` AND interest <> $1`, name, pq.Array(forms))
+	return err
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
+	"github.com/lib/pq"
 	"github.com/yourusername/online-library/internal/models"
 )
 
@@ -17,8 +19,8 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 func (r *UserRepository) Create(user *models.User) error {
 	query := `
-		INSERT INTO users (username, email, password_hash, full_name, role)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (username, email, password_hash, full_name, role, success_score)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at, updated_at
 	`
 	return r.db.QueryRow(
@@ -28,6 +30,7 @@ func (r *UserRepository) Create(user *models.User) error {
 		user.PasswordHash,
 		user.FullName,
 		user.Role,
+		user.SuccessScore,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 }
 
@@ -39,6 +42,8 @@ func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 		       COALESCE(bio, '') as bio,
 		       location_lat, location_lng, 
 		       COALESCE(location_address, '') as location_address,
+		       COALESCE(location_district, '') as location_district,
+		       COALESCE(location_privacy, 'district') as location_privacy,
 		       COALESCE(success_score, 100) as success_score,
 		       COALESCE(books_shared, 0) as books_shared,
 		       COALESCE(books_received, 0) as books_received,
@@ -63,6 +68,8 @@ func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 		&user.LocationLat,
 		&user.LocationLng,
 		&user.LocationAddress,
+		&user.LocationDistrict,
+		&user.LocationPrivacy,
 		&user.SuccessScore,
 		&user.BooksShared,
 		&user.BooksReceived,
@@ -88,6 +95,8 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 		       COALESCE(bio, '') as bio,
 		       location_lat, location_lng, 
 		       COALESCE(location_address, '') as location_address,
+		       COALESCE(location_district, '') as location_district,
+		       COALESCE(location_privacy, 'district') as location_privacy,
 		       COALESCE(success_score, 100) as success_score,
 		       COALESCE(books_shared, 0) as books_shared,
 		       COALESCE(books_received, 0) as books_received,
@@ -112,6 +121,8 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 		&user.LocationLat,
 		&user.LocationLng,
 		&user.LocationAddress,
+		&user.LocationDistrict,
+		&user.LocationPrivacy,
 		&user.SuccessScore,
 		&user.BooksShared,
 		&user.BooksReceived,
@@ -137,6 +148,8 @@ func (r *UserRepository) FindByID(id string) (*models.User, error) {
 		       COALESCE(bio, '') as bio,
 		       location_lat, location_lng, 
 		       COALESCE(location_address, '') as location_address,
+		       COALESCE(location_district, '') as location_district,
+		       COALESCE(location_privacy, 'district') as location_privacy,
 		       COALESCE(success_score, 100) as success_score,
 		       COALESCE(books_shared, 0) as books_shared,
 		       COALESCE(books_received, 0) as books_received,
@@ -161,6 +174,8 @@ func (r *UserRepository) FindByID(id string) (*models.User, error) {
 		&user.LocationLat,
 		&user.LocationLng,
 		&user.LocationAddress,
+		&user.LocationDistrict,
+		&user.LocationPrivacy,
 		&user.SuccessScore,
 		&user.BooksShared,
 		&user.BooksReceived,
@@ -177,3 +192,30 @@ func (r *UserRepository) FindByID(id string) (*models.User, error) {
 	}
 	return user, err
 }
+
+// FindByUsernames looks up users by username, case-insensitively. Only the
+// id and username are loaded.
+func (r *UserRepository) FindByUsernames(usernames []string) ([]*models.User, error) {
+	lowered := make([]string, len(usernames))
+	for i, u := range usernames {
+		lowered[i] = strings.ToLower(u)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, username FROM users WHERE lower(username) = ANY($1)
+	`, pq.Array(lowered))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.Username); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// AchievementService awards badges as members reach milestones. Handlers
+// report what happened and only the metrics the event can move are checked.
+// The event has already been saved when it is reported, so failures are
+// only logged.
+type AchievementService struct {
+	badgeRepo    *repository.BadgeRepository
+	notifService *NotificationService
+}
+
+func NewAchievementService(badgeRepo *repository.BadgeRepository, notifService *NotificationService) *AchievementService {
+	return &AchievementService{
+		badgeRepo:    badgeRepo,
+		notifService: notifService,
+	}
+}
+
+// BookHandedOver counts a share for the giver and the distance travelled
+// for whoever listed the book.
+func (s *AchievementService) BookHandedOver(giverID, listerID string) {
+	s.check(giverID, models.MetricBooksShared)
+	s.check(listerID, models.MetricKmTravelled)
+}
+
+func (s *AchievementService) BookReturned(userID string) {
+	s.check(userID, models.MetricOnTimeReturns)
+}
+
+func (s *AchievementService) IdeaVoted(authorID string) {
+	s.check(authorID, models.MetricUpvotesReceived)
+}
+
+func (s *AchievementService) Donated(userID string) {
+	s.check(userID, models.MetricDonations)
+}
+
+func (s *AchievementService) check(userID string, metric models.BadgeMetric) {
+	if userID == "" {
+		return
+	}
+	awards, err := s.badgeRepo.AwardEarned(metric, userID)
+	if err != nil {
+		log.Println("Failed to award badges:", err)
+		return
+	}
+	for _, a := range awards {
+		s.notifService.NotifyBadgeEarned(a.UserID, a.Badge.Name, a.Badge.Description)
+	}
+}
+
+// Backfill awards every badge already earned, for one member or everyone.
+// Members aren't notified, since the milestones may be long past.
+func (s *AchievementService) Backfill(userID string) ([]*models.UserBadge, error) {
+	var awards []*models.UserBadge
+	for _, metric := range models.BadgeMetrics {
+		a, err := s.badgeRepo.AwardEarned(metric, userID)
+		if err != nil {
+			return awards, err
+		}
+		awards = append(awards, a...)
+	}
+	return awards, nil
+}
+
+// Badges returns a member's badges for their profile.
+func (s *AchievementService) Badges(userID string) ([]dto.BadgeSummary, error) {
+	earned, err := s.badgeRepo.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	badges := make([]dto.BadgeSummary, len(earned))
+	for i, ub := range earned {
+		badges[i] = dto.BadgeSummary{
+			Code:        ub.Badge.Code,
+			Name:        ub.Badge.Name,
+			Description: ub.Badge.Description,
+			Icon:        ub.Badge.Icon.String,
+			AwardedAt:   ub.AwardedAt.Format(time.RFC3339),
+		}
+	}
+	return badges, nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// AuditService records who did what to which resource in audit_logs.
+type AuditService struct {
+	db *sql.DB
+}
+
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+type AuditEntry struct {
+	UserID       string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Details      map[string]interface{}
+	IPAddress    string
+	UserAgent    string
+}
+
+func (s *AuditService) Log(entry AuditEntry) error {
+	var details []byte
+	if entry.Details != nil {
+		var err error
+		if details, err = json.Marshal(entry.Details); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO audit_logs (user_id, action, resource_type, resource_id, details, ip_address, user_agent)
+		VALUES (NULLIF($1, '')::uuid, $2, $3, NULLIF($4, '')::uuid, $5, NULLIF($6, ''), NULLIF($7, ''))
+	`, entry.UserID, entry.Action, entry.ResourceType, entry.ResourceID, details, entry.IPAddress, entry.UserAgent)
+	return err
+}
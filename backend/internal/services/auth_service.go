@@ -12,14 +12,16 @@ import (
 )
 
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret string
+	userRepo      *repository.UserRepository
+	scoreRuleRepo *repository.ScoreRuleRepository
+	jwtSecret     string
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, scoreRuleRepo *repository.ScoreRuleRepository, jwtSecret string) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:      userRepo,
+		scoreRuleRepo: scoreRuleRepo,
+		jwtSecret:     jwtSecret,
 	}
 }
 
@@ -38,6 +40,12 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.AuthResponse, erro
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
+	// New members start on the active rule set's starting score
+	rules, err := s.scoreRuleRepo.GetActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load score rules: %w", err)
+	}
+
 	// Create user
 	user := &models.User{
 		Username:     req.Username,
@@ -45,6 +53,7 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.AuthResponse, erro
 		PasswordHash: string(hashedPassword),
 		FullName:     req.FullName,
 		Role:         string(models.RoleMember),
+		SuccessScore: rules.StartingScore,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
@@ -127,6 +136,26 @@ func (s *AuthService) Login(req dto.LoginRequest) (*dto.AuthResponse, error) {
 	}, nil
 }
 
+// Me returns the signed-in member's account details.
+func (s *AuthService) Me(userID string) (*dto.UserDTO, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserDTO{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		FullName:      user.FullName,
+		Role:          user.Role,
+		AvatarURL:     user.AvatarURL,
+		SuccessScore:  user.SuccessScore,
+		BooksShared:   user.BooksShared,
+		BooksReceived: user.BooksReceived,
+	}, nil
+}
+
 func (s *AuthService) generateToken(userID, role string, duration time.Duration) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yourusername/online-library/internal/contentfilter"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// Kinds of post the content filter screens; they match the report
+// resource types so flagged posts can go straight to the moderation queue.
+const (
+	PostIdea   = string(models.ReportIdea)
+	PostReview = string(models.ReportReview)
+	PostBio    = string(models.ReportBio)
+)
+
+// ContentFilterService screens ideas, reviews and bios before they are
+// saved, and records accepted posts for the duplicate and rate checks.
+type ContentFilterService struct {
+	pipeline    contentfilter.Pipeline
+	submissions *repository.SubmissionRepository
+	reportRepo  *repository.ReportRepository
+}
+
+func NewContentFilterService(wordlist *contentfilter.Wordlist, submissions *repository.SubmissionRepository, reportRepo *repository.ReportRepository) *ContentFilterService {
+	return &ContentFilterService{
+		pipeline: contentfilter.Pipeline{
+			contentfilter.RateLimitFilter{
+				History: submissions,
+				Limits: map[string]contentfilter.Limit{
+					PostIdea:   {Posts: 10, Window: time.Hour},
+					PostReview: {Posts: 20, Window: time.Hour},
+					PostBio:    {Posts: 5, Window: time.Hour},
+				},
+			},
+			wordlist,
+			contentfilter.LinkFilter{FlagAt: 3, RejectAt: 8},
+			contentfilter.DuplicateFilter{
+				History:   submissions,
+				Window:    24 * time.Hour,
+				FlagOwn:   1,
+				RejectOwn: 3,
+				FlagAny:   3,
+				RejectAny: 10,
+			},
+		},
+		submissions: submissions,
+		reportRepo:  reportRepo,
+	}
+}
+
+// Check runs the filters over a post without recording anything.
+func (s *ContentFilterService) Check(post contentfilter.Post) (contentfilter.Result, error) {
+	return s.pipeline.Check(post)
+}
+
+// Record logs a post that was saved after Check and, if it was flagged,
+// queues it for a moderator. The post is already saved, so failures are
+// only logged.
+func (s *ContentFilterService) Record(post contentfilter.Post, resourceID string, result contentfilter.Result) {
+	if err := s.submissions.Record(post, resourceID, result); err != nil {
+		log.Println("Failed to record submission:", err)
+		return
+	}
+	if result.Verdict != contentfilter.Flag {
+		return
+	}
+	err := s.reportRepo.CreateFilterFlag(models.ReportResourceType(post.Kind), resourceID, post.UserID,
+		"Flagged automatically: "+strings.Join(result.Reasons, ", "))
+	if err != nil {
+		log.Println("Failed to queue flagged post:", err)
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// How much each kind of implicit feedback adds to the weight of a book's
+// topics.
+const (
+	SignalRead     = 0.5
+	SignalLike     = 0.3
+	SignalBookmark = 0.2
+	SignalPriority = 0.3
+)
+
+const (
+	interestHalfLife  = 30 * 24 * time.Hour
+	interestDropBelow = 0.05
+)
+
+// InterestService learns interest weights from what members read, like and
+// bookmark, and lets those learned weights fade over time.
+type InterestService struct {
+	db              *sql.DB
+	interestRepo    *repository.InterestRepository
+	matchingService *MatchingService
+}
+
+func NewInterestService(db *sql.DB, interestRepo *repository.InterestRepository, matchingService *MatchingService) *InterestService {
+	return &InterestService{
+		db:              db,
+		interestRepo:    interestRepo,
+		matchingService: matchingService,
+	}
+}
+
+// RecordBookmark nudges the member's interests towards the book's topics.
+func (s *InterestService) RecordBookmark(userID, bookID, bookmarkType string) error {
+	amount := SignalBookmark
+	switch bookmarkType {
+	case "like":
+		amount = SignalLike
+	case "priority":
+		amount = SignalPriority
+	}
+
+	var topics []string
+	err := s.db.QueryRow(`SELECT COALESCE(topics, '{}') FROM books WHERE id = $1`, bookID).Scan(pq.Array(&topics))
+	if err != nil {
+		return err
+	}
+
+	if err := s.interestRepo.Nudge(userID, topics, amount); err != nil {
+		return err
+	}
+
+	_, err = s.matchingService.RecalculatePriorities(RescoreScope{UserID: userID})
+	return err
+}
+
+// Refresh folds in new reading history and decays learned weights by the
+// time elapsed since they were last decayed, then rescores pending requests.
+func (s *InterestService) Refresh() error {
+	if _, err := s.interestRepo.NudgeFromReads(SignalRead); err != nil {
+		return err
+	}
+
+	factor := func(elapsed time.Duration) float64 {
+		return math.Pow(0.5, elapsed.Hours()/interestHalfLife.Hours())
+	}
+	if err := s.interestRepo.Decay(factor, interestDropBelow); err != nil {
+		return err
+	}
+
+	_, err := s.matchingService.RecalculatePriorities(RescoreScope{})
+	return err
+}
+
+// Start runs Refresh now and then every interval in the background.
+func (s *InterestService) Start(interval time.Duration) {
+	go func() {
+		if err := s.Refresh(); err != nil {
+			log.Println("Interest refresh failed:", err)
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.Refresh(); err != nil {
+				log.Println("Interest refresh failed:", err)
+			}
+		}
+	}()
+}
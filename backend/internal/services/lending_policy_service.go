@@ -0,0 +1,163 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// PolicyError is a lending action the member's standing does not allow.
+type PolicyError struct {
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+func denied(format string, args ...interface{}) error {
+	return &PolicyError{Message: fmt.Sprintf(format, args...)}
+}
+
+// LendingPolicyService decides what a member may borrow from their score
+// tier. Every lending endpoint goes through it, so requests, handovers and
+// renewals are limited the same way everywhere.
+type LendingPolicyService struct {
+	db          *sql.DB
+	ruleRepo    *repository.ScoreRuleRepository
+	requestRepo *repository.RequestRepository
+}
+
+func NewLendingPolicyService(db *sql.DB, ruleRepo *repository.ScoreRuleRepository, requestRepo *repository.RequestRepository) *LendingPolicyService {
+	return &LendingPolicyService{
+		db:          db,
+		ruleRepo:    ruleRepo,
+		requestRepo: requestRepo,
+	}
+}
+
+// Rules returns the active score rules, which include the tiers.
+func (p *LendingPolicyService) Rules() (*models.ScoreRuleSet, error) {
+	return p.ruleRepo.GetActive()
+}
+
+// Standing works out a member's tier from their score and lending record.
+func (p *LendingPolicyService) Standing(userID string) (*models.MemberStanding, error) {
+	rules, err := p.Rules()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &models.MemberStanding{}
+	if err := p.db.QueryRow(`SELECT success_score FROM users WHERE id = $1`, userID).Scan(&s.SuccessScore); err != nil {
+		return nil, err
+	}
+	s.ActiveLoans, s.CompletedLoans, err = p.requestRepo.CountLoans(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Tier = rules.TierFor(s.SuccessScore, s.CompletedLoans)
+	s.NextTier = rules.NextTier(s.Tier)
+	s.CanRequest = s.SuccessScore >= rules.MinRequestScore && s.ActiveLoans < s.Tier.MaxActiveLoans
+	return s, nil
+}
+
+// TierSummary condenses a member's standing for profiles.
+func TierSummary(s *models.MemberStanding) *dto.TierSummary {
+	summary := &dto.TierSummary{
+		Level:          s.Tier.Level,
+		Name:           s.Tier.Name,
+		MaxActiveLoans: s.Tier.MaxActiveLoans,
+		MaxLoanDays:    s.Tier.MaxLoanDays,
+		MaxRenewals:    s.Tier.MaxRenewals,
+		PriorityBonus:  s.Tier.PriorityBonus,
+		CompletedLoans: s.CompletedLoans,
+	}
+	if s.NextTier != nil {
+		summary.Next = &dto.NextTierSummary{
+			Name:              s.NextTier.Name,
+			MinScore:          s.NextTier.MinScore,
+			MinCompletedLoans: s.NextTier.MinCompletedLoans,
+		}
+	}
+	return summary
+}
+
+// CheckRequest decides whether a member may request a book.
+func (p *LendingPolicyService) CheckRequest(userID string) (*models.MemberStanding, error) {
+	rules, err := p.Rules()
+	if err != nil {
+		return nil, err
+	}
+	s, err := p.Standing(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.SuccessScore < rules.MinRequestScore {
+		return s, denied("Your success score (%d) is too low. Minimum required: %d", s.SuccessScore, rules.MinRequestScore)
+	}
+	if s.ActiveLoans >= s.Tier.MaxActiveLoans {
+		return s, denied("%s members can borrow %d books at a time. Return a book before requesting another", s.Tier.Name, s.Tier.MaxActiveLoans)
+	}
+	return s, nil
+}
+
+// CheckLoan decides whether a book may be handed to a member now, which
+// they may not if they reached their loan limit since requesting it.
+func (p *LendingPolicyService) CheckLoan(userID string) (*models.MemberStanding, error) {
+	s, err := p.Standing(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.ActiveLoans >= s.Tier.MaxActiveLoans {
+		return s, denied("The requester already borrows %d books, the most their %s tier allows", s.ActiveLoans, s.Tier.Name)
+	}
+	return s, nil
+}
+
+// LoanDueDate is when a loan starting at from falls due. The holder may ask
+// for a shorter loan than the borrower's tier allows, never a longer one.
+func LoanDueDate(tier models.ScoreTier, requestedDays int, from time.Time) time.Time {
+	days := tier.MaxLoanDays
+	if requestedDays > 0 && requestedDays < days {
+		days = requestedDays
+	}
+	return from.AddDate(0, 0, days)
+}
+
+// CheckRenewal decides whether a loan may be renewed and returns the new
+// due date: one more loan period from the current due date, or from now if
+// the loan is already overdue. Books other members are waiting for can't be
+// renewed.
+func (p *LendingPolicyService) CheckRenewal(loan *models.BookRequest) (time.Time, error) {
+	s, err := p.Standing(loan.UserID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if loan.Renewals >= s.Tier.MaxRenewals {
+		if s.Tier.MaxRenewals == 0 {
+			return time.Time{}, denied("%s members can't renew loans", s.Tier.Name)
+		}
+		return time.Time{}, denied("%s members can renew a loan %d times", s.Tier.Name, s.Tier.MaxRenewals)
+	}
+
+	waiting, err := p.requestRepo.CountPending(loan.BookID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if waiting > 0 {
+		return time.Time{}, denied("Other members are waiting for this book")
+	}
+
+	from := time.Now()
+	if loan.DueDate.Valid && loan.DueDate.Time.After(from) {
+		from = loan.DueDate.Time
+	}
+	return LoanDueDate(s.Tier, 0, from), nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/geocoding"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// neighbourhoodCellDeg is the grid cell (~2 km) neighbourhood locations snap
+// to. Snapping is deterministic so repeated requests can't be averaged out.
+const neighbourhoodCellDeg = 0.02
+
+const (
+	// NearbyRadiusStepKm is what search radii are rounded up to, so the
+	// radius can't be narrowed down to find where a boundary falls.
+	NearbyRadiusStepKm = 5
+	// nearbySearchMarginKm widens the candidate search so holders whose
+	// public point (a district centre, say) is closer than their home are
+	// still found.
+	nearbySearchMarginKm = 60
+)
+
+type LocationService struct {
+	geocoder  geocoding.Geocoder
+	gazetteer *geocoding.Gazetteer
+}
+
+func NewLocationService(geocoder geocoding.Geocoder, gazetteer *geocoding.Gazetteer) *LocationService {
+	return &LocationService{
+		geocoder:  geocoder,
+		gazetteer: gazetteer,
+	}
+}
+
+// ResolvedLocation is what gets stored on a user profile. Lat/Lng are nil
+// when nothing could be resolved.
+type ResolvedLocation struct {
+	Lat       *float64
+	Lng       *float64
+	Address   string
+	District  string
+	Precision geocoding.Precision
+}
+
+// Resolve reconciles the location fields of a profile update. Explicit
+// coordinates win but must roughly agree with the address if both are given;
+// a picked district stands in for coordinates; otherwise the address is
+// geocoded.
+func (s *LocationService) Resolve(address, district string, lat, lng *float64) (*ResolvedLocation, error) {
+	if (lat == nil) != (lng == nil) {
+		return nil, fmt.Errorf("location_lat and location_lng must be provided together")
+	}
+
+	if lat != nil {
+		if address != "" {
+			if loc, err := s.geocoder.Geocode(address); err == nil {
+				if geocoding.DistanceKm(*lat, *lng, loc.Lat, loc.Lng) > loc.Precision.Tolerance() {
+					return nil, fmt.Errorf("location_address does not match the given coordinates")
+				}
+			}
+		}
+		resolved := &ResolvedLocation{Lat: lat, Lng: lng, Address: address, Precision: geocoding.PrecisionExact}
+		if nearest := s.gazetteer.NearestDistrict(*lat, *lng); nearest != nil {
+			resolved.District = nearest.Name
+		}
+		return resolved, nil
+	}
+
+	if district != "" {
+		loc, err := s.gazetteer.District(district)
+		if err != nil {
+			return nil, fmt.Errorf("unknown district: %s", district)
+		}
+		if address == "" {
+			address = fmt.Sprintf("%s, %s", loc.Name, loc.Division)
+		}
+		return s.fromLocation(loc, address), nil
+	}
+
+	if address != "" {
+		if loc, err := s.geocoder.Geocode(address); err == nil {
+			return s.fromLocation(loc, address), nil
+		}
+	}
+
+	return &ResolvedLocation{Address: address}, nil
+}
+
+func (s *LocationService) Geocode(address string) (*geocoding.Location, error) {
+	return s.geocoder.Geocode(address)
+}
+
+func (s *LocationService) Districts() []geocoding.Location {
+	return s.gazetteer.Districts()
+}
+
+func (s *LocationService) fromLocation(loc *geocoding.Location, address string) *ResolvedLocation {
+	resolved := &ResolvedLocation{
+		Lat:       &loc.Lat,
+		Lng:       &loc.Lng,
+		Address:   address,
+		District:  loc.District,
+		Precision: loc.Precision,
+	}
+	if resolved.District == "" {
+		if nearest := s.gazetteer.NearestDistrict(loc.Lat, loc.Lng); nearest != nil {
+			resolved.District = nearest.Name
+		}
+	}
+	return resolved
+}
+
+// PublicLocation fuzzes a stored location according to the owner's privacy
+// setting. Owners always see their own exact location. Returns nil when there
+// is nothing to show.
+func (s *LocationService) PublicLocation(lat, lng sql.NullFloat64, district string, privacy models.LocationPrivacy, isOwner bool) *dto.PublicLocation {
+	if !lat.Valid || !lng.Valid {
+		return nil
+	}
+	if isOwner {
+		privacy = models.PrivacyExact
+	}
+
+	switch privacy {
+	case models.PrivacyExact:
+		return &dto.PublicLocation{Lat: lat.Float64, Lng: lng.Float64, District: district, Precision: string(privacy)}
+	case models.PrivacyNeighbourhood:
+		return &dto.PublicLocation{
+			Lat:       snapToCell(lat.Float64),
+			Lng:       snapToCell(lng.Float64),
+			District:  district,
+			Precision: string(privacy),
+		}
+	case models.PrivacyHidden:
+		return nil
+	}
+
+	// District (the default): show only the district centre.
+	loc, err := s.gazetteer.District(district)
+	if err != nil {
+		loc = s.gazetteer.NearestDistrict(lat.Float64, lng.Float64)
+	}
+	if loc == nil {
+		return nil
+	}
+	return &dto.PublicLocation{Lat: loc.Lat, Lng: loc.Lng, District: loc.Name, Precision: string(models.PrivacyDistrict)}
+}
+
+func snapToCell(v float64) float64 {
+	return math.Floor(v/neighbourhoodCellDeg)*neighbourhoodCellDeg + neighbourhoodCellDeg/2
+}
+
+// PublicDistanceKm rounds a distance to a member into a bucket as coarse as
+// their location privacy. Returns nil when they hide their location.
+func PublicDistanceKm(km float64, privacy models.LocationPrivacy) *float64 {
+	var bucketed float64
+	switch privacy {
+	case models.PrivacyHidden:
+		return nil
+	case models.PrivacyExact:
+		bucketed = math.Round(km*10) / 10
+	case models.PrivacyNeighbourhood:
+		bucketed = math.Ceil(km/2) * 2
+	default:
+		bucketed = math.Ceil(km/5) * 5
+	}
+	return &bucketed
+}
+
+// NearbySearch builds the repository search for books within radiusKm of a
+// point, measured to each holder's public point as PublicLocation shows it.
+func (s *LocationService) NearbySearch(lat, lng, radiusKm float64, limit int) repository.NearbySearch {
+	search := repository.NearbySearch{
+		Lat:      lat,
+		Lng:      lng,
+		RadiusKm: radiusKm,
+		SearchKm: radiusKm + nearbySearchMarginKm,
+		Limit:    limit,
+		CellDeg:  neighbourhoodCellDeg,
+	}
+	for _, d := range s.gazetteer.Districts() {
+		search.Districts = append(search.Districts, d.Name)
+		search.DistrictLats = append(search.DistrictLats, d.Lat)
+		search.DistrictLngs = append(search.DistrictLngs, d.Lng)
+	}
+	return search
+}
+
+// BucketNearby rounds each result's distance as coarsely as its holder's
+// location privacy. Results are already ordered by distance to public points,
+// so the order reveals nothing a profile doesn't already show.
+func BucketNearby(books []*models.NearbyBook) {
+	for _, book := range books {
+		if km := PublicDistanceKm(book.DistanceKm, book.HolderPrivacy); km != nil {
+			book.DistanceKm = *km
+		}
+	}
+}
@@ -2,187 +2,378 @@ package services
 
 import (
 	"database/sql"
+	"fmt"
 	"math"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/online-library/internal/geocoding"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
 )
 
 type MatchingService struct {
-	db *sql.DB
+	db            *sql.DB
+	policyRepo    *repository.MatchingPolicyRepository
+	lendingPolicy *LendingPolicyService
 }
 
-func NewMatchingService(db *sql.DB) *MatchingService {
-	return &MatchingService{db: db}
+func NewMatchingService(db *sql.DB, policyRepo *repository.MatchingPolicyRepository, lendingPolicy *LendingPolicyService) *MatchingService {
+	return &MatchingService{
+		db:            db,
+		policyRepo:    policyRepo,
+		lendingPolicy: lendingPolicy,
+	}
 }
 
-// Calculate distance between two points using Haversine formula
-func (m *MatchingService) calculateDistance(lat1, lng1, lat2, lng2 float64) float64 {
-	const earthRadius = 6371 // km
+// MatchInputs are the raw facts about a requester that a policy scores.
+type MatchInputs struct {
+	SuccessScore  int
+	InterestMatch float64  // 0-100
+	DistanceKm    *float64 // nil when either party has no location
+	WaitDays      float64
+	TierBonus     float64 // the requester's score tier priority bonus
+}
 
-	dLat := (lat2 - lat1) * math.Pi / 180
-	dLng := (lng2 - lng1) * math.Pi / 180
+// ScoreRequest applies a matching policy to one requester. It is pure so the
+// live service and offline tools rank requests identically.
+func ScoreRequest(policy *models.MatchingPolicy, in MatchInputs) models.ScoreBreakdown {
+	distance := policy.MissingDistanceKm
+	if in.DistanceKm != nil {
+		distance = *in.DistanceKm
+	}
 
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
-			math.Sin(dLng/2)*math.Sin(dLng/2)
+	b := models.ScoreBreakdown{
+		PolicyVersion:      policy.Version,
+		SuccessComponent:   float64(in.SuccessScore) * policy.SuccessWeight,
+		InterestComponent:  in.InterestMatch * policy.InterestWeight,
+		DistanceComponent:  distanceScore(policy, distance) * policy.DistanceWeight,
+		WaitComponent:      waitScore(policy, in.WaitDays)*policy.WaitWeight + agingBoost(policy, in.WaitDays),
+		TierComponent:      in.TierBonus,
+		InterestMatchScore: in.InterestMatch,
+		DistanceKm:         in.DistanceKm,
+	}
+	b.PriorityScore = b.SuccessComponent + b.InterestComponent + b.DistanceComponent + b.WaitComponent + b.TierComponent
+	return b
+}
 
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return earthRadius * c
+// distanceScore maps a distance to 0-100, closer being higher.
+func distanceScore(policy *models.MatchingPolicy, km float64) float64 {
+	if km <= 0 {
+		return 100
+	}
+	switch policy.DistanceDecay {
+	case models.DecayExponential:
+		return 100 * math.Exp(-km/policy.DistanceScaleKm)
+	default:
+		return math.Max(0, 100*(1-km/policy.DistanceScaleKm))
+	}
 }
 
-// Calculate interest match score between user and book
-func (m *MatchingService) calculateInterestMatch(userID, bookID string) (float64, error) {
-	// Get user interests
-	userInterests := make(map[string]float64)
-	rows, err := m.db.Query(`
-		SELECT interest, weight FROM user_interests WHERE user_id = $1
-	`, userID)
-	if err != nil {
-		return 0, err
+// waitScore maps time spent pending to 0-100, saturating after
+// WaitSaturationDays.
+func waitScore(policy *models.MatchingPolicy, days float64) float64 {
+	if policy.WaitSaturationDays <= 0 || days <= 0 {
+		return 0
 	}
-	defer rows.Close()
+	return 100 * math.Min(days, float64(policy.WaitSaturationDays)) / float64(policy.WaitSaturationDays)
+}
 
-	for rows.Next() {
-		var interest string
-		var weight float64
-		if err := rows.Scan(&interest, &weight); err != nil {
-			return 0, err
-		}
-		userInterests[interest] = weight
+// agingBoost grows without bound the longer a request waits, so every
+// request eventually outranks newer ones regardless of success score.
+func agingBoost(policy *models.MatchingPolicy, days float64) float64 {
+	if days <= 0 {
+		return 0
 	}
+	return policy.AgingPointsPerDay * days
+}
 
-	// Get book topics
-	var topics []string
-	err = m.db.QueryRow(`
-		SELECT topics FROM books WHERE id = $1
-	`, bookID).Scan(&topics)
-	if err != nil {
-		return 0, err
+// Candidate is a pending request competing for a book.
+type Candidate struct {
+	RequestID     string
+	UserID        string
+	PriorityScore float64
+	RequestedAt   time.Time
+	SuccessScore  int
+	RecentWins    int // contested books won within the policy's win period
+}
+
+// RankCandidates orders competing requests best first. When more than one
+// request competes, members who already reached the policy's win cap are
+// moved behind everyone else (but not dropped, so a book is never left
+// unallocated). Equal scores are settled by the policy's tie-break.
+func RankCandidates(policy *models.MatchingPolicy, candidates []Candidate) []Candidate {
+	ranked := make([]Candidate, len(candidates))
+	copy(ranked, candidates)
+
+	capped := func(c Candidate) bool {
+		return len(ranked) > 1 && policy.MaxWinsPerPeriod > 0 && c.RecentWins >= policy.MaxWinsPerPeriod
 	}
 
-	// Calculate match score
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if capped(a) != capped(b) {
+			return !capped(a)
+		}
+		// Scores are stored with two decimals; compare at that precision
+		sa, sb := math.Round(a.PriorityScore*100), math.Round(b.PriorityScore*100)
+		if sa != sb {
+			return sa > sb
+		}
+		switch policy.TieBreak {
+		case models.TieBreakFewestRecentWins:
+			if a.RecentWins != b.RecentWins {
+				return a.RecentWins < b.RecentWins
+			}
+		case models.TieBreakLowestSuccessScore:
+			if a.SuccessScore != b.SuccessScore {
+				return a.SuccessScore < b.SuccessScore
+			}
+		}
+		return a.RequestedAt.Before(b.RequestedAt)
+	})
+
+	return ranked
+}
+
+// Calculate distance between two points using Haversine formula
+func (m *MatchingService) calculateDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	return geocoding.DistanceKm(lat1, lng1, lat2, lng2)
+}
+
+// InterestMatch scores how well a member's interest weights cover a book's
+// topics, normalized to 0-100 where 100 means every topic is at
+// MaxInterestWeight.
+func InterestMatch(interests map[string]float64, topics []string) float64 {
 	var matchScore float64
 	for _, topic := range topics {
-		if weight, exists := userInterests[topic]; exists {
+		if weight, exists := interests[topic]; exists {
 			matchScore += weight
 		}
 	}
+	return normalizeInterestMatch(matchScore, len(topics))
+}
 
-	// Normalize to 0-100
-	if len(topics) > 0 {
-		matchScore = (matchScore / float64(len(topics))) * 100
+func normalizeInterestMatch(weightSum float64, topicCount int) float64 {
+	if topicCount == 0 {
+		return 0
 	}
-
-	return matchScore, nil
+	return math.Min(weightSum/(float64(topicCount)*repository.MaxInterestWeight), 1) * 100
 }
 
-// Calculate priority score for book request
-func (m *MatchingService) CalculatePriorityScore(userID, bookID string, holderLat, holderLng float64) (float64, float64, error) {
-	// Get user data
-	var successScore int
-	var userLat, userLng sql.NullFloat64
-	err := m.db.QueryRow(`
-		SELECT success_score, location_lat, location_lng 
-		FROM users WHERE id = $1
-	`, userID).Scan(&successScore, &userLat, &userLng)
-	if err != nil {
-		return 0, 0, err
-	}
+// ActivePolicy returns the matching policy currently in force.
+func (m *MatchingService) ActivePolicy() (*models.MatchingPolicy, error) {
+	return m.policyRepo.GetActive()
+}
 
-	// Calculate distance
-	var distance float64
-	if userLat.Valid && userLng.Valid {
-		distance = m.calculateDistance(holderLat, holderLng, userLat.Float64, userLng.Float64)
-	} else {
-		distance = 10000 // Default high distance if location not set
+// SelectBestMatch returns the request that should receive a book next.
+// Scores are refreshed first so aging reflects the current time.
+func (m *MatchingService) SelectBestMatch(bookID string) (string, error) {
+	if err := m.UpdateRequestPriorities(bookID); err != nil {
+		return "", err
 	}
 
-	// Calculate interest match
-	interestScore, err := m.calculateInterestMatch(userID, bookID)
+	ranked, err := m.RankPending(bookID)
 	if err != nil {
-		return 0, 0, err
+		return "", err
 	}
-
-	// Calculate priority score
-	// Formula: (SuccessScore * 0.4) + (InterestMatch * 0.3) + (DistanceScore * 0.3)
-	// Distance score: closer = higher (inverse relationship)
-	distanceScore := 100.0
-	if distance > 0 {
-		distanceScore = math.Max(0, 100-(distance/10)) // 10km = 10 points reduction
+	if len(ranked) == 0 {
+		return "", sql.ErrNoRows
 	}
-
-	priorityScore := (float64(successScore)*0.4 + interestScore*0.3 + distanceScore*0.3)
-
-	return priorityScore, interestScore, nil
+	return ranked[0].RequestID, nil
 }
 
-// Select best match from multiple requests
-func (m *MatchingService) SelectBestMatch(bookID string) (string, error) {
-	query := `
-		SELECT br.id, br.user_id, br.priority_score
+// RankPending orders a book's pending requests the way allocation does: by
+// stored priority score, with the active policy's win cap and tie-break
+// applied. Requesters the lending policy wouldn't hand the book to now (they
+// reached their loan limit since requesting) are left out until they can.
+func (m *MatchingService) RankPending(bookID string) ([]Candidate, error) {
+	policy, err := m.ActivePolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	// A win is an approved request for a book someone else was also waiting for
+	rows, err := m.db.Query(`
+		SELECT br.id, br.user_id, br.priority_score, br.requested_at, u.success_score,
+		       (SELECT COUNT(*) FROM book_requests w
+		        WHERE w.user_id = br.user_id AND w.status = 'approved'
+		          AND w.processed_at >= CURRENT_TIMESTAMP - make_interval(days => $2)
+		          AND EXISTS (
+		              SELECT 1 FROM book_requests o
+		              WHERE o.book_id = w.book_id AND o.id <> w.id
+		                AND o.requested_at <= w.processed_at
+		                AND (o.processed_at IS NULL OR o.processed_at >= w.processed_at)
+		          )) as recent_wins
 		FROM book_requests br
+		JOIN users u ON u.id = br.user_id
 		WHERE br.book_id = $1 AND br.status = 'pending'
-		ORDER BY br.priority_score DESC, br.requested_at ASC
-		LIMIT 1
-	`
-
-	var requestID, userID string
-	var priorityScore float64
-	err := m.db.QueryRow(query, bookID).Scan(&requestID, &userID, &priorityScore)
+	`, bookID, policy.WinPeriodDays)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer rows.Close()
+
+	var candidates []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.RequestID, &c.UserID, &c.PriorityScore, &c.RequestedAt, &c.SuccessScore, &c.RecentWins); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var eligible []Candidate
+	for _, c := range RankCandidates(policy, candidates) {
+		if _, err := m.lendingPolicy.CheckLoan(c.UserID); err != nil {
+			if _, ok := err.(*PolicyError); ok {
+				continue
+			}
+			return nil, err
+		}
+		eligible = append(eligible, c)
+	}
+	return eligible, nil
+}
 
-	return requestID, nil
+// RescoreScope limits a priority recalculation to one book and/or one
+// requester. The zero value rescores every pending request.
+type RescoreScope struct {
+	BookID string
+	UserID string
 }
 
 // Update request priority scores when book holder changes
 func (m *MatchingService) UpdateRequestPriorities(bookID string) error {
-	// Get current holder location
-	var holderLat, holderLng sql.NullFloat64
-	err := m.db.QueryRow(`
-		SELECT u.location_lat, u.location_lng
-		FROM books b
-		JOIN users u ON b.current_holder_id = u.id
-		WHERE b.id = $1
-	`, bookID).Scan(&holderLat, &holderLng)
+	_, err := m.RecalculatePriorities(RescoreScope{BookID: bookID})
+	return err
+}
+
+// RecalculatePriorities rescores all pending requests in scope under the
+// active policy. Inputs for every request are read in one query and written
+// back in one UPDATE, inside a single transaction. Requests are locked in id
+// order so overlapping rescores can't deadlock. Returns how many requests
+// were rescored.
+func (m *MatchingService) RecalculatePriorities(scope RescoreScope) (int, error) {
+	policy, err := m.ActivePolicy()
+	if err != nil {
+		return 0, err
+	}
+	rules, err := m.lendingPolicy.Rules()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if !holderLat.Valid || !holderLng.Valid {
-		return nil // Skip if no location
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	// Get all pending requests
-	rows, err := m.db.Query(`
-		SELECT id, user_id FROM book_requests
-		WHERE book_id = $1 AND status = 'pending'
-	`, bookID)
+	// Holder is the current reader, or the member who added the book when it is on the shelf
+	rows, err := tx.Query(`
+		SELECT br.id, br.requested_at, COALESCE(u.success_score, 100),
+		       u.location_lat, u.location_lng, h.location_lat, h.location_lng,
+		       COALESCE((
+		           SELECT SUM(ui.weight)
+		           FROM unnest(b.topics) AS t(topic)
+		           JOIN user_interests ui ON ui.user_id = br.user_id AND ui.interest = t.topic
+		       ), 0) as interest_weight_sum,
+		       COALESCE(cardinality(b.topics), 0) as topic_count,
+		       (SELECT COUNT(*) FROM book_requests l
+		        WHERE l.user_id = br.user_id AND l.status = 'approved' AND l.returned_at IS NOT NULL) as completed_loans
+		FROM book_requests br
+		JOIN users u ON u.id = br.user_id
+		JOIN books b ON b.id = br.book_id
+		LEFT JOIN users h ON h.id = COALESCE(b.current_holder_id, b.created_by)
+		WHERE br.status = 'pending'
+		  AND ($1 = '' OR br.book_id::text = $1)
+		  AND ($2 = '' OR br.user_id::text = $2)
+		ORDER BY br.id
+		FOR UPDATE OF br
+	`, scope.BookID, scope.UserID)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("failed to load pending requests: %w", err)
 	}
-	defer rows.Close()
 
-	// Update each request
+	now := time.Now()
+	var (
+		ids                                     []string
+		priority, interest, success, dist, wait []float64
+		interestComponent, tier                 []float64
+		distanceKm                              []sql.NullFloat64
+	)
 	for rows.Next() {
-		var requestID, userID string
-		if err := rows.Scan(&requestID, &userID); err != nil {
-			continue
+		var id string
+		var requestedAt time.Time
+		var successScore, topicCount, completedLoans int
+		var userLat, userLng, holderLat, holderLng sql.NullFloat64
+		var weightSum float64
+		if err := rows.Scan(&id, &requestedAt, &successScore, &userLat, &userLng,
+			&holderLat, &holderLng, &weightSum, &topicCount, &completedLoans); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read pending request: %w", err)
 		}
 
-		priorityScore, interestScore, err := m.CalculatePriorityScore(userID, bookID, holderLat.Float64, holderLng.Float64)
-		if err != nil {
-			continue
+		in := MatchInputs{
+			SuccessScore:  successScore,
+			InterestMatch: normalizeInterestMatch(weightSum, topicCount),
+			WaitDays:      now.Sub(requestedAt).Hours() / 24,
+			TierBonus:     rules.TierFor(successScore, completedLoans).PriorityBonus,
+		}
+		if userLat.Valid && userLng.Valid && holderLat.Valid && holderLng.Valid {
+			distance := m.calculateDistance(holderLat.Float64, holderLng.Float64, userLat.Float64, userLng.Float64)
+			in.DistanceKm = &distance
 		}
+		b := ScoreRequest(policy, in)
 
-		_, err = m.db.Exec(`
-			UPDATE book_requests
-			SET priority_score = $1, interest_match_score = $2
-			WHERE id = $3
-		`, priorityScore, interestScore, requestID)
-		if err != nil {
-			continue
+		ids = append(ids, id)
+		priority = append(priority, b.PriorityScore)
+		interest = append(interest, b.InterestMatchScore)
+		success = append(success, b.SuccessComponent)
+		interestComponent = append(interestComponent, b.InterestComponent)
+		dist = append(dist, b.DistanceComponent)
+		wait = append(wait, b.WaitComponent)
+		tier = append(tier, b.TierComponent)
+		if b.DistanceKm != nil {
+			distanceKm = append(distanceKm, sql.NullFloat64{Float64: *b.DistanceKm, Valid: true})
+		} else {
+			distanceKm = append(distanceKm, sql.NullFloat64{})
 		}
 	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("failed to load pending requests: %w", err)
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return 0, tx.Commit()
+	}
 
-	return nil
+	_, err = tx.Exec(`
+		UPDATE book_requests br
+		SET priority_score = v.priority_score, interest_match_score = v.interest_match_score,
+		    distance_km = v.distance_km, success_component = v.success_component,
+		    interest_component = v.interest_component, distance_component = v.distance_component,
+		    wait_component = v.wait_component, tier_component = v.tier_component,
+		    policy_version = $10, scored_at = $11
+		FROM unnest($1::uuid[], $2::float8[], $3::float8[], $4::float8[], $5::float8[], $6::float8[], $7::float8[], $8::float8[], $9::float8[])
+		     AS v(id, priority_score, interest_match_score, distance_km, success_component,
+		          interest_component, distance_component, wait_component, tier_component)
+		WHERE br.id = v.id
+	`, pq.Array(ids), pq.Array(priority), pq.Array(interest), pq.Array(distanceKm), pq.Array(success),
+		pq.Array(interestComponent), pq.Array(dist), pq.Array(wait), pq.Array(tier), policy.Version, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update request priorities: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
 }
@@ -71,6 +71,77 @@ func (n *NotificationService) NotifyReviewReceived(userID, reviewerName string)
 	)
 }
 
+func (n *NotificationService) NotifyReviewDisputed(reviewerID, disputeID string) error {
+	return n.Create(
+		reviewerID,
+		"review_disputed",
+		"Review Disputed",
+		"A review you left has been disputed and will be looked at by a moderator.",
+		fmt.Sprintf("/reviews/disputes/%s", disputeID),
+	)
+}
+
+// NotifyDisputeResolved tells one party to a dispute how it ended.
+func (n *NotificationService) NotifyDisputeResolved(userID, disputeID string, removed bool) error {
+	message := "A moderator reviewed the dispute and the review stays in place."
+	if removed {
+		message = "A moderator reviewed the dispute and the review has been removed."
+	}
+	return n.Create(
+		userID,
+		"review_dispute_resolved",
+		"Review Dispute Resolved",
+		message,
+		fmt.Sprintf("/reviews/disputes/%s", disputeID),
+	)
+}
+
+// contentLabels name each reportable kind of content in notifications.
+var contentLabels = map[string]string{
+	"idea":     "idea",
+	"comment":  "comment",
+	"review":   "review",
+	"bio":      "profile bio",
+	"donation": "donation message",
+}
+
+// NotifyReportResolved tells a reporter whether their report led to action.
+func (n *NotificationService) NotifyReportResolved(userID, resourceType string, actioned bool) error {
+	message := fmt.Sprintf("A moderator looked at the %s you reported and found no violation.", contentLabels[resourceType])
+	if actioned {
+		message = fmt.Sprintf("A moderator looked at the %s you reported and took action. Thank you.", contentLabels[resourceType])
+	}
+	return n.Create(userID, "report_resolved", "Report Reviewed", message, "/notifications")
+}
+
+// NotifyContentModerated tells an author what a moderator did about their
+// content: "hide", "delete" or "warn".
+func (n *NotificationService) NotifyContentModerated(userID, resourceType, action string) error {
+	label := contentLabels[resourceType]
+	var message string
+	switch action {
+	case "hide":
+		message = fmt.Sprintf("Your %s was hidden by a moderator after it was reported.", label)
+	case "delete":
+		message = fmt.Sprintf("Your %s was removed by a moderator after it was reported.", label)
+	default:
+		message = fmt.Sprintf("A moderator issued a warning about your %s. Repeated violations may lead to removal.", label)
+	}
+	return n.Create(userID, "content_moderated", "Moderation Notice", message, "/profile")
+}
+
+// NotifyContentHidden tells an author their content was hidden automatically
+// after several reports, until a moderator looks at it.
+func (n *NotificationService) NotifyContentHidden(userID, resourceType string) error {
+	return n.Create(
+		userID,
+		"content_hidden",
+		"Content Hidden",
+		fmt.Sprintf("Your %s has been hidden after several reports and will be looked at by a moderator.", contentLabels[resourceType]),
+		"/profile",
+	)
+}
+
 func (n *NotificationService) NotifySuccessScoreChange(userID string, change int, reason string) error {
 	action := "increased"
 	if change < 0 {
@@ -85,6 +156,16 @@ func (n *NotificationService) NotifySuccessScoreChange(userID string, change int
 	)
 }
 
+func (n *NotificationService) NotifyBadgeEarned(userID, badgeName, description string) error {
+	return n.Create(
+		userID,
+		"badge_earned",
+		"Badge Earned",
+		fmt.Sprintf("You earned the '%s' badge: %s", badgeName, description),
+		"/profile",
+	)
+}
+
 func (n *NotificationService) NotifyIdeaVote(userID, voterName, ideaTitle string, isUpvote bool) error {
 	voteType := "upvoted"
 	if !isUpvote {
@@ -99,6 +180,16 @@ func (n *NotificationService) NotifyIdeaVote(userID, voterName, ideaTitle string
 	)
 }
 
+func (n *NotificationService) NotifyMention(userID, authorName, ideaID, commentID string) error {
+	return n.Create(
+		userID,
+		"mention",
+		"You Were Mentioned",
+		fmt.Sprintf("%s mentioned you in a comment", authorName),
+		fmt.Sprintf("/ideas/%s#comment-%s", ideaID, commentID),
+	)
+}
+
 func (n *NotificationService) GetUserNotifications(userID string, limit int, unreadOnly bool) ([]map[string]interface{}, error) {
 	query := `
 		SELECT id, type, title, message, link, is_read, created_at
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// contentWeight is the share of the blended score that comes from interest
+// matching; the rest comes from co-readership.
+const contentWeight = 0.5
+
+type RecommendationService struct {
+	db       *sql.DB
+	bookRepo *repository.BookRepository
+}
+
+func NewRecommendationService(db *sql.DB, bookRepo *repository.BookRepository) *RecommendationService {
+	return &RecommendationService{
+		db:       db,
+		bookRepo: bookRepo,
+	}
+}
+
+type seedBook struct {
+	title string
+	verb  string // "read" or "liked"
+}
+
+type candidateScore struct {
+	content      float64
+	topInterest  string
+	topInterestW float64
+	cf           float64
+	topSeed      string
+	topSeedW     float64
+}
+
+// Recommend blends content-based scoring (book topics and tags against the
+// member's interest weights) with item-to-item collaborative filtering over
+// reading history and likes. Books the member has read or currently holds
+// are never recommended.
+func (s *RecommendationService) Recommend(userID string, limit int) ([]*models.Recommendation, error) {
+	interests, err := s.loadInterests(userID)
+	if err != nil {
+		return nil, err
+	}
+	seeds, excluded, err := s.loadSeeds(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := s.bookRepo.FindAll(map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]*candidateScore)
+	for _, book := range books {
+		if excluded[book.ID] {
+			continue
+		}
+		cs := &candidateScore{}
+		terms := append(append([]string{}, book.Topics...), book.Tags...)
+		for _, term := range terms {
+			if w, ok := interests[term]; ok && w > cs.topInterestW {
+				cs.topInterest, cs.topInterestW = term, w
+			}
+		}
+		cs.content = InterestMatch(interests, terms) / 100
+		scores[book.ID] = cs
+	}
+
+	if len(seeds) > 0 {
+		if err := s.scoreCoReadership(userID, seeds, scores); err != nil {
+			return nil, err
+		}
+	}
+
+	// Normalize co-readership to 0-1 so it blends evenly with content
+	var maxCF float64
+	for _, cs := range scores {
+		maxCF = math.Max(maxCF, cs.cf)
+	}
+
+	var recs []*models.Recommendation
+	for _, book := range books {
+		cs, ok := scores[book.ID]
+		if !ok {
+			continue
+		}
+		content := math.Min(cs.content, 1)
+		cf := 0.0
+		if maxCF > 0 {
+			cf = cs.cf / maxCF
+		}
+
+		rec := &models.Recommendation{Book: book}
+		switch {
+		case len(interests) == 0:
+			rec.Score = cf
+		case len(seeds) == 0:
+			rec.Score = content
+		default:
+			rec.Score = contentWeight*content + (1-contentWeight)*cf
+		}
+		if rec.Score <= 0 {
+			continue
+		}
+
+		if (1-contentWeight)*cf >= contentWeight*content && cs.topSeed != "" {
+			seed := seeds[cs.topSeed]
+			rec.Reason = fmt.Sprintf("Because you %s \"%s\"", seed.verb, seed.title)
+		} else {
+			rec.Reason = fmt.Sprintf("Matches your interest in %s", cs.topInterest)
+		}
+		recs = append(recs, rec)
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].Book.TotalReads > recs[j].Book.TotalReads
+	})
+
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs, nil
+}
+
+func (s *RecommendationService) loadInterests(userID string) (map[string]float64, error) {
+	rows, err := s.db.Query(`SELECT interest, weight FROM user_interests WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	interests := make(map[string]float64)
+	for rows.Next() {
+		var interest string
+		var weight float64
+		if err := rows.Scan(&interest, &weight); err != nil {
+			return nil, err
+		}
+		interests[interest] = weight
+	}
+	return interests, rows.Err()
+}
+
+// loadSeeds returns the books the member has read or liked (keyed by book
+// ID), and the set of books that must not be recommended: everything read or
+// currently held.
+func (s *RecommendationService) loadSeeds(userID string) (map[string]seedBook, map[string]bool, error) {
+	rows, err := s.db.Query(`
+		SELECT b.id, b.title, 'read' as verb, true as exclude
+		FROM reading_history rh JOIN books b ON b.id = rh.book_id
+		WHERE rh.reader_id = $1
+		UNION ALL
+		SELECT b.id, b.title, 'liked', false
+		FROM user_bookmarks ub JOIN books b ON b.id = ub.book_id
+		WHERE ub.user_id = $1 AND ub.bookmark_type IN ('like', 'bookmark')
+		UNION ALL
+		SELECT b.id, b.title, 'hold', true
+		FROM books b
+		WHERE b.current_holder_id = $1
+	`, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	seeds := make(map[string]seedBook)
+	excluded := make(map[string]bool)
+	for rows.Next() {
+		var id, title, verb string
+		var exclude bool
+		if err := rows.Scan(&id, &title, &verb, &exclude); err != nil {
+			return nil, nil, err
+		}
+		if exclude {
+			excluded[id] = true
+		}
+		if verb == "hold" {
+			continue
+		}
+		// Prefer "liked" as the explanation when a book was both read and liked
+		if _, seen := seeds[id]; !seen || verb == "liked" {
+			seeds[id] = seedBook{title: title, verb: verb}
+		}
+	}
+	return seeds, excluded, rows.Err()
+}
+
+// scoreCoReadership adds item-to-item cosine similarity between the member's
+// seed books and every candidate, based on other members who read or liked
+// both.
+func (s *RecommendationService) scoreCoReadership(userID string, seeds map[string]seedBook, scores map[string]*candidateScore) error {
+	rows, err := s.db.Query(`
+		WITH interactions AS (
+			SELECT DISTINCT reader_id as user_id, book_id FROM reading_history
+			UNION
+			SELECT user_id, book_id FROM user_bookmarks WHERE bookmark_type IN ('like', 'bookmark')
+		),
+		popularity AS (
+			SELECT book_id, COUNT(*) as n FROM interactions GROUP BY book_id
+		)
+		SELECT s.book_id, o.book_id, COUNT(*) as co, ps.n, pc.n
+		FROM interactions s
+		JOIN interactions o ON o.user_id = s.user_id AND o.book_id <> s.book_id
+		JOIN popularity ps ON ps.book_id = s.book_id
+		JOIN popularity pc ON pc.book_id = o.book_id
+		WHERE s.user_id <> $1
+		  AND s.book_id IN (SELECT book_id FROM interactions WHERE user_id = $1)
+		GROUP BY s.book_id, o.book_id, ps.n, pc.n
+	`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seedID, candID string
+		var co, seedN, candN int
+		if err := rows.Scan(&seedID, &candID, &co, &seedN, &candN); err != nil {
+			return err
+		}
+		cs, ok := scores[candID]
+		if !ok {
+			continue
+		}
+		if _, isSeed := seeds[seedID]; !isSeed {
+			continue
+		}
+		sim := float64(co) / math.Sqrt(float64(seedN*candN))
+		cs.cf += sim
+		if sim > cs.topSeedW {
+			cs.topSeed, cs.topSeedW = seedID, sim
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// Weights of each signal in a book-to-book similarity score.
+const (
+	similarityTopicWeight    = 0.35
+	similarityTagWeight      = 0.25
+	similarityAuthorWeight   = 0.10
+	similarityCategoryWeight = 0.10
+	similarityCoreaderWeight = 0.20
+
+	// similarBooksPerBook is how many neighbours are kept per book
+	similarBooksPerBook = 20
+)
+
+// SimilarityService precomputes "similar books" so the book page only has to
+// read a small table.
+type SimilarityService struct {
+	db       *sql.DB
+	bookRepo *repository.BookRepository
+}
+
+func NewSimilarityService(db *sql.DB, bookRepo *repository.BookRepository) *SimilarityService {
+	return &SimilarityService{
+		db:       db,
+		bookRepo: bookRepo,
+	}
+}
+
+type bookPair struct {
+	a, b string
+}
+
+// Refresh recomputes similarities for every pair of books: Jaccard over
+// topics and tags, same author/category, and cosine co-occurrence among
+// members who read, liked or bookmarked both.
+func (s *SimilarityService) Refresh() error {
+	books, err := s.bookRepo.FindAll(map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+
+	coreaders, err := s.loadCoreaders()
+	if err != nil {
+		return err
+	}
+
+	topics := make([]map[string]bool, len(books))
+	tags := make([]map[string]bool, len(books))
+	for i, book := range books {
+		topics[i] = termSet(book.Topics)
+		tags[i] = termSet(book.Tags)
+	}
+
+	neighbours := make(map[string][]models.BookSimilarity)
+	for i := range books {
+		for j := i + 1; j < len(books); j++ {
+			a, b := books[i], books[j]
+			sim := models.BookSimilarity{
+				TopicScore:    jaccard(topics[i], topics[j]),
+				TagScore:      jaccard(tags[i], tags[j]),
+				SameAuthor:    a.Author != "" && strings.EqualFold(a.Author, b.Author),
+				SameCategory:  a.Category != "" && strings.EqualFold(a.Category, b.Category),
+				CoreaderScore: coreaders[bookPair{a.ID, b.ID}],
+			}
+			sim.Score = similarityTopicWeight*sim.TopicScore +
+				similarityTagWeight*sim.TagScore +
+				similarityCoreaderWeight*sim.CoreaderScore
+			if sim.SameAuthor {
+				sim.Score += similarityAuthorWeight
+			}
+			if sim.SameCategory {
+				sim.Score += similarityCategoryWeight
+			}
+			if sim.Score <= 0 {
+				continue
+			}
+
+			forward, backward := sim, sim
+			forward.BookID, forward.SimilarBookID = a.ID, b.ID
+			backward.BookID, backward.SimilarBookID = b.ID, a.ID
+			neighbours[a.ID] = append(neighbours[a.ID], forward)
+			neighbours[b.ID] = append(neighbours[b.ID], backward)
+		}
+	}
+
+	var sims []models.BookSimilarity
+	for _, list := range neighbours {
+		sort.Slice(list, func(i, j int) bool { return list[i].Score > list[j].Score })
+		if len(list) > similarBooksPerBook {
+			list = list[:similarBooksPerBook]
+		}
+		sims = append(sims, list...)
+	}
+
+	return s.bookRepo.ReplaceSimilarities(sims)
+}
+
+// Start refreshes similarities now and then every interval in the background.
+func (s *SimilarityService) Start(interval time.Duration) {
+	go func() {
+		if err := s.Refresh(); err != nil {
+			log.Println("Similarity refresh failed:", err)
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.Refresh(); err != nil {
+				log.Println("Similarity refresh failed:", err)
+			}
+		}
+	}()
+}
+
+// loadCoreaders returns cosine co-occurrence for every pair of books that
+// share at least one reader, keyed both ways.
+func (s *SimilarityService) loadCoreaders() (map[bookPair]float64, error) {
+	rows, err := s.db.Query(`
+		WITH interactions AS (
+			SELECT DISTINCT reader_id as user_id, book_id FROM reading_history
+			UNION
+			SELECT user_id, book_id FROM user_bookmarks
+		),
+		popularity AS (
+			SELECT book_id, COUNT(*) as n FROM interactions GROUP BY book_id
+		)
+		SELECT a.book_id, b.book_id, COUNT(*) as co, pa.n, pb.n
+		FROM interactions a
+		JOIN interactions b ON b.user_id = a.user_id AND b.book_id > a.book_id
+		JOIN popularity pa ON pa.book_id = a.book_id
+		JOIN popularity pb ON pb.book_id = b.book_id
+		GROUP BY a.book_id, b.book_id, pa.n, pb.n
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coreaders := make(map[bookPair]float64)
+	for rows.Next() {
+		var a, b string
+		var co, na, nb int
+		if err := rows.Scan(&a, &b, &co, &na, &nb); err != nil {
+			return nil, err
+		}
+		score := float64(co) / math.Sqrt(float64(na*nb))
+		coreaders[bookPair{a, b}] = score
+		coreaders[bookPair{b, a}] = score
+	}
+	return coreaders, rows.Err()
+}
+
+func termSet(terms []string) map[string]bool {
+	set := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			set[t] = true
+		}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if b[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -2,31 +2,24 @@ package services
 
 import (
 	"database/sql"
-	"fmt"
+	"sort"
 	"time"
+
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
 )
 
 type SuccessScoreService struct {
-	db *sql.DB
+	db       *sql.DB
+	ruleRepo *repository.ScoreRuleRepository
 }
 
-func NewSuccessScoreService(db *sql.DB) *SuccessScoreService {
-	return &SuccessScoreService{db: db}
+func NewSuccessScoreService(db *sql.DB, ruleRepo *repository.ScoreRuleRepository) *SuccessScoreService {
+	return &SuccessScoreService{db: db, ruleRepo: ruleRepo}
 }
 
-const (
-	ScoreReturnOnTime   = 10
-	ScoreReturnLate     = -15
-	ScorePositiveReview = 5
-	ScoreNegativeReview = -10
-	ScoreIdeaPosted     = 3
-	ScoreIdeaUpvote     = 1
-	ScoreIdeaDownvote   = -1
-	ScoreLostBook       = -50
-	ScoreBookDonated    = 20
-	ScoreMoneyDonated   = 10
-)
-
+// UpdateScore applies a fixed adjustment that no scoring rule covers. It
+// is kept as-is when scores are recomputed.
 func (s *SuccessScoreService) UpdateScore(userID string, change int, reason string, refType string, refID *string) error {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -52,8 +45,8 @@ func (s *SuccessScoreService) UpdateScore(userID string, change int, reason stri
 	}
 
 	_, err = tx.Exec(`
-		INSERT INTO success_score_history (user_id, change_amount, reason, reference_type, reference_id)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO success_score_history (user_id, change_amount, reason, event_type, reference_type, reference_id)
+		VALUES ($1, $2, $3, NULL, $4, $5)
 	`, userID, change, reason, refType, refIDVal)
 	if err != nil {
 		return err
@@ -62,49 +55,265 @@ func (s *SuccessScoreService) UpdateScore(userID string, change int, reason stri
 	return tx.Commit()
 }
 
+// record applies a scoring event under the active rules and returns the
+// change it made. Positive units award the event; negative units reverse
+// earlier awards of it on the same reference, and do nothing when there is
+// nothing left to reverse.
+func (s *SuccessScoreService) record(userID string, event models.ScoreEvent, units int, reason, refType, refID string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	change, err := s.recordTx(tx, userID, event, units, reason, refType, refID)
+	if err != nil {
+		return 0, err
+	}
+	return change, tx.Commit()
+}
+
+// recordTx is record inside the caller's transaction.
+func (s *SuccessScoreService) recordTx(tx *sql.Tx, userID string, event models.ScoreEvent, units int, reason, refType, refID string) (int, error) {
+	rules, err := s.ruleRepo.GetActive()
+	if err != nil {
+		return 0, err
+	}
+	rule := rules.Rule(event)
+
+	// Lock the member so concurrent events see each other when capping
+	if _, err := tx.Exec(`SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return 0, err
+	}
+
+	var awarded, refChange, refUnits int
+	if units > 0 && rule.PeriodCap > 0 {
+		err := tx.QueryRow(`
+			SELECT COALESCE(SUM(change_amount), 0)
+			FROM success_score_history
+			WHERE user_id = $1 AND event_type = $2 AND units > 0
+			  AND created_at > CURRENT_TIMESTAMP - make_interval(days => $3)
+		`, userID, event, rule.CapPeriodDays).Scan(&awarded)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if units < 0 {
+		err := tx.QueryRow(`
+			SELECT COALESCE(SUM(change_amount), 0), COALESCE(SUM(units), 0)
+			FROM success_score_history
+			WHERE user_id = $1 AND event_type = $2 AND reference_type = $3 AND reference_id = $4
+		`, userID, event, refType, refID).Scan(&refChange, &refUnits)
+		if err != nil {
+			return 0, err
+		}
+		if refUnits <= 0 {
+			return 0, nil
+		}
+		if -units > refUnits {
+			units = -refUnits
+		}
+	}
+	change := scoreChange(rule, units, awarded, refChange, refUnits)
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET success_score = success_score + $1,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, change, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Zero changes are recorded too, so a later rule change can re-value them
+	_, err = tx.Exec(`
+		INSERT INTO success_score_history (user_id, change_amount, reason, event_type, units, rule_set_version, reference_type, reference_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::uuid)
+	`, userID, change, reason, event, units, rules.Version, refType, refID)
+	if err != nil {
+		return 0, err
+	}
+
+	return change, nil
+}
+
+func (s *SuccessScoreService) award(userID string, event models.ScoreEvent, reason, refType, refID string) error {
+	_, err := s.record(userID, event, 1, reason, refType, refID)
+	return err
+}
+
+// scoreChange is what an event is worth under rule. Awards are limited by
+// what the event has already accounted for within the rule's cap period;
+// reversals take back the reference's average award per unit, so they undo
+// what was actually given, caps included.
+func scoreChange(rule models.ScoreRule, units, awarded, refChange, refUnits int) int {
+	if units < 0 {
+		if refUnits <= 0 {
+			return 0
+		}
+		return refChange * units / refUnits
+	}
+
+	change := rule.Delta * units
+	if rule.PeriodCap > 0 {
+		room := rule.PeriodCap - abs(awarded)
+		if room < 0 {
+			room = 0
+		}
+		if abs(change) > room {
+			change = room * sign(change)
+		}
+	}
+	return change
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
 func (s *SuccessScoreService) ProcessReturnOnTime(userID, bookID string) error {
-	return s.UpdateScore(userID, ScoreReturnOnTime, "Returned book on time", "book", &bookID)
+	return s.award(userID, models.EventReturnOnTime, "Returned book on time", "book", bookID)
 }
 
 func (s *SuccessScoreService) ProcessReturnLate(userID, bookID string) error {
-	return s.UpdateScore(userID, ScoreReturnLate, "Returned book late", "book", &bookID)
+	return s.award(userID, models.EventReturnLate, "Returned book late", "book", bookID)
 }
 
 func (s *SuccessScoreService) ProcessPositiveReview(userID, reviewID string) error {
-	return s.UpdateScore(userID, ScorePositiveReview, "Received positive review", "review", &reviewID)
+	return s.award(userID, models.EventPositiveReview, "Received positive review", "review", reviewID)
 }
 
 func (s *SuccessScoreService) ProcessNegativeReview(userID, reviewID string) error {
-	return s.UpdateScore(userID, ScoreNegativeReview, "Received negative review", "review", &reviewID)
+	return s.award(userID, models.EventNegativeReview, "Received negative review", "review", reviewID)
+}
+
+// ProcessReviewUpdate re-scores an edited review whose effect on the
+// reviewee changed, e.g. from positive to none. An empty event means the
+// review counted neither way.
+func (s *SuccessScoreService) ProcessReviewUpdate(userID, reviewID string, before, after models.ScoreEvent) error {
+	if before == after {
+		return nil
+	}
+	if before != "" {
+		if _, err := s.record(userID, before, -1, "Review updated", "review", reviewID); err != nil {
+			return err
+		}
+	}
+	if after != "" {
+		return s.award(userID, after, "Review updated", "review", reviewID)
+	}
+	return nil
+}
+
+// ReverseReview undoes whatever a review did to the reviewee's score,
+// including later edits, and returns the change applied.
+func (s *SuccessScoreService) ReverseReview(userID, reviewID, reason string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	total, err := s.ReverseReviewTx(tx, userID, reviewID, reason)
+	if err != nil {
+		return 0, err
+	}
+	return total, tx.Commit()
+}
+
+// ReverseReviewTx is ReverseReview inside the caller's transaction, so the
+// reversal commits or fails together with whatever removed the review.
+func (s *SuccessScoreService) ReverseReviewTx(tx *sql.Tx, userID, reviewID, reason string) (int, error) {
+	total := 0
+	for _, event := range []models.ScoreEvent{models.EventPositiveReview, models.EventNegativeReview} {
+		change, err := s.recordTx(tx, userID, event, -1, reason, "review", reviewID)
+		if err != nil {
+			return 0, err
+		}
+		total += change
+	}
+	return total, nil
 }
 
 func (s *SuccessScoreService) ProcessIdeaPosted(userID, ideaID string) error {
-	return s.UpdateScore(userID, ScoreIdeaPosted, "Posted reading idea", "idea", &ideaID)
+	return s.award(userID, models.EventIdeaPosted, "Posted reading idea", "idea", ideaID)
 }
 
-func (s *SuccessScoreService) ProcessIdeaUpvote(userID, ideaID string) error {
-	return s.UpdateScore(userID, ScoreIdeaUpvote, "Idea received upvote", "idea", &ideaID)
+// ReverseIdeaPosted takes back the award for posting an idea once it is
+// deleted. It does nothing if the award was never given or already reversed.
+func (s *SuccessScoreService) ReverseIdeaPosted(userID, ideaID string) error {
+	_, err := s.record(userID, models.EventIdeaPosted, -1, "Idea deleted", "idea", ideaID)
+	return err
 }
 
-func (s *SuccessScoreService) ProcessIdeaDownvote(userID, ideaID string) error {
-	return s.UpdateScore(userID, ScoreIdeaDownvote, "Idea received downvote", "idea", &ideaID)
+// ReverseIdeaPostedTx is ReverseIdeaPosted inside the caller's transaction.
+func (s *SuccessScoreService) ReverseIdeaPostedTx(tx *sql.Tx, userID, ideaID string) error {
+	_, err := s.recordTx(tx, userID, models.EventIdeaPosted, -1, "Idea deleted", "idea", ideaID)
+	return err
+}
+
+// ProcessIdeaVoteChangeTx applies the net effect of a member changing their
+// vote on an idea, inside the transaction that records the vote: the previous
+// vote is reversed and the new one counted.
+func (s *SuccessScoreService) ProcessIdeaVoteChangeTx(tx *sql.Tx, userID, ideaID, previous, current string) error {
+	if previous == current {
+		return nil
+	}
+
+	reason := "Idea vote changed"
+	switch {
+	case previous == models.VoteNone && current == models.VoteUp:
+		reason = "Idea received upvote"
+	case previous == models.VoteNone && current == models.VoteDown:
+		reason = "Idea received downvote"
+	case current == models.VoteNone:
+		reason = "Idea vote withdrawn"
+	}
+	if event := ideaVoteEvent(previous); event != "" {
+		if _, err := s.recordTx(tx, userID, event, -1, reason, "idea", ideaID); err != nil {
+			return err
+		}
+	}
+	if event := ideaVoteEvent(current); event != "" {
+		if _, err := s.recordTx(tx, userID, event, 1, reason, "idea", ideaID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ideaVoteEvent(voteType string) models.ScoreEvent {
+	switch voteType {
+	case models.VoteUp:
+		return models.EventIdeaUpvote
+	case models.VoteDown:
+		return models.EventIdeaDownvote
+	}
+	return ""
 }
 
 func (s *SuccessScoreService) ProcessLostBook(userID, bookID string) error {
-	return s.UpdateScore(userID, ScoreLostBook, "Lost book", "book", &bookID)
+	return s.award(userID, models.EventLostBook, "Lost book", "book", bookID)
 }
 
 func (s *SuccessScoreService) ProcessBookDonation(userID, donationID string) error {
-	return s.UpdateScore(userID, ScoreBookDonated, "Donated book", "donation", &donationID)
+	return s.award(userID, models.EventBookDonated, "Donated book", "donation", donationID)
 }
 
 func (s *SuccessScoreService) ProcessMoneyDonation(userID, donationID string) error {
-	return s.UpdateScore(userID, ScoreMoneyDonated, "Made financial contribution", "donation", &donationID)
+	return s.award(userID, models.EventMoneyDonated, "Made financial contribution", "donation", donationID)
 }
 
 func (s *SuccessScoreService) GetScoreHistory(userID string, limit int) ([]map[string]interface{}, error) {
 	query := `
-		SELECT id, change_amount, reason, reference_type, reference_id, created_at
+		SELECT id, change_amount, reason, COALESCE(event_type, ''), reference_type, reference_id, created_at
 		FROM success_score_history
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -119,12 +328,12 @@ func (s *SuccessScoreService) GetScoreHistory(userID string, limit int) ([]map[s
 
 	var history []map[string]interface{}
 	for rows.Next() {
-		var id, reason, refType string
+		var id, reason, event, refType string
 		var changeAmount int
 		var refID sql.NullString
 		var createdAt time.Time
 
-		err := rows.Scan(&id, &changeAmount, &reason, &refType, &refID, &createdAt)
+		err := rows.Scan(&id, &changeAmount, &reason, &event, &refType, &refID, &createdAt)
 		if err != nil {
 			return nil, err
 		}
@@ -136,6 +345,9 @@ func (s *SuccessScoreService) GetScoreHistory(userID string, limit int) ([]map[s
 			"reference_type": refType,
 			"created_at":     createdAt.Format(time.RFC3339),
 		}
+		if event != "" {
+			entry["event_type"] = event
+		}
 		if refID.Valid {
 			entry["reference_id"] = refID.String
 		}
@@ -145,16 +357,166 @@ func (s *SuccessScoreService) GetScoreHistory(userID string, limit int) ([]map[s
 	return history, nil
 }
 
-func (s *SuccessScoreService) CanUserRequestBook(userID string) (bool, string, error) {
-	var successScore int
-	err := s.db.QueryRow("SELECT success_score FROM users WHERE id = $1", userID).Scan(&successScore)
+// scoreEntry is one row of a member's score history, as replayed by
+// Recompute.
+type scoreEntry struct {
+	id        string
+	event     models.ScoreEvent
+	units     int
+	refType   string
+	refID     string
+	change    int
+	version   int
+	createdAt time.Time
+}
+
+// replayScore re-values a member's history, oldest first, under rules and
+// returns the total change. Each entry's change is updated in place, and
+// caps and reversals play out exactly as record applies them live. Entries
+// without an event are fixed adjustments and keep their amount.
+func replayScore(rules *models.ScoreRuleSet, entries []*scoreEntry) int {
+	type refKey struct {
+		event          models.ScoreEvent
+		refType, refID string
+	}
+	type refTotal struct{ change, units int }
+	refs := make(map[refKey]refTotal)
+
+	total := 0
+	for i, e := range entries {
+		if e.event == "" {
+			total += e.change
+			continue
+		}
+		rule := rules.Rule(e.event)
+
+		awarded := 0
+		if e.units > 0 && rule.PeriodCap > 0 {
+			since := e.createdAt.AddDate(0, 0, -rule.CapPeriodDays)
+			for _, prev := range entries[:i] {
+				if prev.event == e.event && prev.units > 0 && prev.createdAt.After(since) {
+					awarded += prev.change
+				}
+			}
+		}
+
+		key := refKey{e.event, e.refType, e.refID}
+		ref := refs[key]
+		e.change = scoreChange(rule, e.units, awarded, ref.change, ref.units)
+		e.version = rules.Version
+		refs[key] = refTotal{ref.change + e.change, ref.units + e.units}
+		total += e.change
+	}
+	return total
+}
+
+// Recompute rebuilds success scores from the score history under rules,
+// for one member or, with an empty userID, everyone. It returns the members
+// whose score or history changes, largest difference first. Unless apply is
+// set nothing is written, which makes it a dry run.
+func (s *SuccessScoreService) Recompute(rules *models.ScoreRuleSet, userID string, apply bool) ([]*models.ScoreRecalculation, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return false, "", err
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Applying locks the members so no live event lands mid-rebuild
+	lock := ""
+	if apply {
+		lock = "FOR UPDATE"
+	}
+	rows, err := tx.Query(`
+		SELECT id, username, success_score FROM users
+		WHERE $1 = '' OR id::text = $1
+		ORDER BY id `+lock, userID)
+	if err != nil {
+		return nil, err
+	}
+	var results []*models.ScoreRecalculation
+	for rows.Next() {
+		r := &models.ScoreRecalculation{}
+		if err := rows.Scan(&r.UserID, &r.Username, &r.CurrentScore); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(`
+		SELECT id, user_id, COALESCE(event_type, ''), units, COALESCE(reference_type, ''),
+		       COALESCE(reference_id::text, ''), change_amount, COALESCE(rule_set_version, 0), created_at
+		FROM success_score_history
+		WHERE $1 = '' OR user_id::text = $1
+		ORDER BY user_id, created_at, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[string][]*scoreEntry)
+	for rows.Next() {
+		var owner string
+		e := &scoreEntry{}
+		err := rows.Scan(&e.id, &owner, &e.event, &e.units, &e.refType, &e.refID, &e.change, &e.version, &e.createdAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		history[owner] = append(history[owner], e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if successScore < 20 {
-		return false, fmt.Sprintf("Your success score (%d) is too low. Minimum required: 20", successScore), nil
+	changed := []*models.ScoreRecalculation{}
+	for _, r := range results {
+		entries := history[r.UserID]
+		before := make([]scoreEntry, len(entries))
+		for i, e := range entries {
+			before[i] = *e
+		}
+
+		r.RebuiltScore = rules.StartingScore + replayScore(rules, entries)
+		r.Difference = r.RebuiltScore - r.CurrentScore
+		for i, e := range entries {
+			if e.change == before[i].change && e.version == before[i].version {
+				continue
+			}
+			r.ChangedEntries++
+			if apply {
+				_, err := tx.Exec(`
+					UPDATE success_score_history SET change_amount = $1, rule_set_version = $2 WHERE id = $3
+				`, e.change, e.version, e.id)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if r.Difference == 0 && r.ChangedEntries == 0 {
+			continue
+		}
+		if apply && r.Difference != 0 {
+			_, err := tx.Exec(`
+				UPDATE users SET success_score = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+			`, r.RebuiltScore, r.UserID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		changed = append(changed, r)
 	}
 
-	return true, "", nil
+	sort.SliceStable(changed, func(i, j int) bool {
+		return abs(changed[i].Difference) > abs(changed[j].Difference)
+	})
+
+	if apply {
+		return changed, tx.Commit()
+	}
+	return changed, nil
 }
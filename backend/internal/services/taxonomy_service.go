@@ -0,0 +1,59 @@
+package services
+
+import (
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+// TaxonomyService maps free-text categories, tags, topics and interests onto
+// the controlled vocabulary so that variants of the same term match.
+type TaxonomyService struct {
+	taxonomyRepo *repository.TaxonomyRepository
+}
+
+func NewTaxonomyService(taxonomyRepo *repository.TaxonomyRepository) *TaxonomyService {
+	return &TaxonomyService{taxonomyRepo: taxonomyRepo}
+}
+
+// NormalizeBook rewrites the book's category, tags and topics to their
+// canonical names in place.
+func (s *TaxonomyService) NormalizeBook(book *models.Book) error {
+	if book.Category != "" {
+		category, err := s.Normalize(models.TermCategory, book.Category)
+		if err != nil {
+			return err
+		}
+		book.Category = category
+	}
+
+	tags, err := s.taxonomyRepo.Canonicalize(models.TermTag, book.Tags)
+	if err != nil {
+		return err
+	}
+	book.Tags = tags
+
+	topics, err := s.taxonomyRepo.Canonicalize(models.TermTopic, book.Topics)
+	if err != nil {
+		return err
+	}
+	book.Topics = topics
+
+	return nil
+}
+
+// NormalizeInterests maps interests onto canonical topics, since interest
+// matching compares them against book topics.
+func (s *TaxonomyService) NormalizeInterests(interests []string) ([]string, error) {
+	return s.taxonomyRepo.Canonicalize(models.TermTopic, interests)
+}
+
+func (s *TaxonomyService) Normalize(kind models.TermKind, value string) (string, error) {
+	names, err := s.taxonomyRepo.Canonicalize(kind, []string{value})
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	return names[0], nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/yourusername/online-library/internal/dto"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/repository"
+)
+
+const (
+	// Weight of the community mean in the smoothed rating, counted in reviews
+	trustPriorWeight = 5.0
+	// Prior used before the community has any rated reviews
+	trustDefaultPrior = 3.0
+	// Reviews newer than this form the "recent" side of the trend
+	trustRecentWindow = 90 * 24 * time.Hour
+	// Reviews needed on each side before a trend is reported
+	trustTrendMinReviews = 3
+	// Change in average needed to call the trend improving or declining
+	trustTrendThreshold = 0.3
+)
+
+const (
+	TrendImproving    = "improving"
+	TrendDeclining    = "declining"
+	TrendSteady       = "steady"
+	TrendInsufficient = "insufficient_data"
+)
+
+// TrustService turns the reviews a member has received into a summary that
+// can be shown on their profile or next to their book requests.
+type TrustService struct {
+	reviewRepo *repository.ReviewRepository
+}
+
+func NewTrustService(reviewRepo *repository.ReviewRepository) *TrustService {
+	return &TrustService{reviewRepo: reviewRepo}
+}
+
+func (s *TrustService) Summary(userID string) (*dto.TrustSummary, error) {
+	summaries, err := s.Summaries([]string{userID})
+	if err != nil {
+		return nil, err
+	}
+	return summaries[userID], nil
+}
+
+// Summaries builds trust summaries for several users with one query.
+func (s *TrustService) Summaries(userIDs []string) (map[string]*dto.TrustSummary, error) {
+	prior, err := s.reviewRepo.GetGlobalAverage()
+	if err != nil {
+		return nil, err
+	}
+	priorMean := trustDefaultPrior
+	if prior.Valid {
+		priorMean = prior.Float64
+	}
+
+	stats, err := s.reviewRepo.GetStats(userIDs, time.Now().Add(-trustRecentWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*dto.TrustSummary, len(stats))
+	for id, st := range stats {
+		summaries[id] = BuildTrustSummary(st, priorMean)
+	}
+	return summaries, nil
+}
+
+// BuildTrustSummary is the pure part of Summaries.
+func BuildTrustSummary(st *models.ReviewStats, priorMean float64) *dto.TrustSummary {
+	summary := &dto.TrustSummary{
+		Rating:        round2((priorMean*trustPriorWeight + st.RatingSum) / (trustPriorWeight + float64(st.RatedCount))),
+		ReviewCount:   st.ReviewCount,
+		Behavior:      dto.DimensionRating{Average: roundedAvg(st.BehaviorAvg.Float64, st.BehaviorAvg.Valid), Count: st.BehaviorCount},
+		BookCondition: dto.DimensionRating{Average: roundedAvg(st.ConditionAvg.Float64, st.ConditionAvg.Valid), Count: st.ConditionCount},
+		Communication: dto.DimensionRating{Average: roundedAvg(st.CommunicationAvg.Float64, st.CommunicationAvg.Valid), Count: st.CommunicationCount},
+		RecentAverage: roundedAvg(st.RecentAvg.Float64, st.RecentAvg.Valid),
+		RecentCount:   st.RecentCount,
+		Trend:         TrendInsufficient,
+	}
+	if st.RatedCount > 0 {
+		summary.RawAverage = roundedAvg(st.RatingSum/float64(st.RatedCount), true)
+	}
+
+	if st.RecentCount >= trustTrendMinReviews && st.EarlierCount >= trustTrendMinReviews {
+		switch diff := st.RecentAvg.Float64 - st.EarlierAvg.Float64; {
+		case diff >= trustTrendThreshold:
+			summary.Trend = TrendImproving
+		case diff <= -trustTrendThreshold:
+			summary.Trend = TrendDeclining
+		default:
+			summary.Trend = TrendSteady
+		}
+	}
+
+	return summary
+}
+
+func roundedAvg(v float64, valid bool) *float64 {
+	if !valid {
+		return nil
+	}
+	r := round2(v)
+	return &r
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,228 @@
+package simulation
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// History is everything the simulator needs, loaded up front so a replay
+// runs entirely in memory.
+type History struct {
+	Requests    []Request
+	Allocations []Allocation // historical hand-overs, oldest first
+	Users       map[string]*User
+	BookTopics  map[string][]string
+	BookOwners  map[string]string // member who added the book
+}
+
+type Request struct {
+	ID          string
+	BookID      string
+	UserID      string
+	Status      string
+	RequestedAt time.Time
+	ProcessedAt sql.NullTime
+}
+
+// Allocation is a moment a book changed hands, taken from reading_history.
+type Allocation struct {
+	BookID   string
+	ReaderID string
+	At       time.Time
+}
+
+type User struct {
+	ID        string
+	Score     int // current success score
+	Lat       sql.NullFloat64
+	Lng       sql.NullFloat64
+	Interests map[string]float64
+	changes   []scoreChange // newest first
+	returns   []time.Time   // when each completed loan was returned
+}
+
+type scoreChange struct {
+	amount int
+	at     time.Time
+}
+
+// ScoreAt reconstructs a member's success score at t by unwinding later
+// changes recorded in success_score_history.
+func (u *User) ScoreAt(t time.Time) int {
+	score := u.Score
+	for _, c := range u.changes {
+		if !c.at.After(t) {
+			break
+		}
+		score -= c.amount
+	}
+	return score
+}
+
+// CompletedLoansAt counts the loans a member had returned by t, which with
+// their score decides their tier.
+func (u *User) CompletedLoansAt(t time.Time) int {
+	n := 0
+	for _, r := range u.returns {
+		if !r.After(t) {
+			n++
+		}
+	}
+	return n
+}
+
+// Load reads request and reading history between since and until.
+func Load(db *sql.DB, since, until time.Time) (*History, error) {
+	h := &History{
+		Users:      make(map[string]*User),
+		BookTopics: make(map[string][]string),
+		BookOwners: make(map[string]string),
+	}
+
+	if err := h.loadUsers(db); err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+	if err := h.loadBooks(db); err != nil {
+		return nil, fmt.Errorf("failed to load books: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT id, book_id, user_id, status, requested_at, processed_at
+		FROM book_requests
+		WHERE requested_at < $2 AND (processed_at IS NULL OR processed_at >= $1)
+		ORDER BY requested_at
+	`, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requests: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r Request
+		if err := rows.Scan(&r.ID, &r.BookID, &r.UserID, &r.Status, &r.RequestedAt, &r.ProcessedAt); err != nil {
+			return nil, err
+		}
+		h.Requests = append(h.Requests, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT book_id, reader_id, start_date
+		FROM reading_history
+		WHERE start_date >= $1 AND start_date < $2
+		ORDER BY start_date
+	`, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reading history: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Allocation
+		if err := rows.Scan(&a.BookID, &a.ReaderID, &a.At); err != nil {
+			return nil, err
+		}
+		h.Allocations = append(h.Allocations, a)
+	}
+	return h, rows.Err()
+}
+
+func (h *History) loadUsers(db *sql.DB) error {
+	rows, err := db.Query(`SELECT id, COALESCE(success_score, 100), location_lat, location_lng FROM users`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		u := &User{Interests: make(map[string]float64)}
+		if err := rows.Scan(&u.ID, &u.Score, &u.Lat, &u.Lng); err != nil {
+			return err
+		}
+		h.Users[u.ID] = u
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`SELECT user_id, interest, weight FROM user_interests`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, interest string
+		var weight float64
+		if err := rows.Scan(&userID, &interest, &weight); err != nil {
+			return err
+		}
+		if u, ok := h.Users[userID]; ok {
+			u.Interests[interest] = weight
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`SELECT user_id, change_amount, created_at FROM success_score_history ORDER BY created_at DESC`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		var c scoreChange
+		if err := rows.Scan(&userID, &c.amount, &c.at); err != nil {
+			return err
+		}
+		if u, ok := h.Users[userID]; ok {
+			u.changes = append(u.changes, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`
+		SELECT user_id, returned_at FROM book_requests
+		WHERE status = 'approved' AND returned_at IS NOT NULL
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		var returnedAt time.Time
+		if err := rows.Scan(&userID, &returnedAt); err != nil {
+			return err
+		}
+		if u, ok := h.Users[userID]; ok {
+			u.returns = append(u.returns, returnedAt)
+		}
+	}
+	return rows.Err()
+}
+
+func (h *History) loadBooks(db *sql.DB) error {
+	rows, err := db.Query(`SELECT id, COALESCE(topics, '{}'), created_by FROM books`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var topics []string
+		var owner sql.NullString
+		if err := rows.Scan(&id, pq.Array(&topics), &owner); err != nil {
+			return err
+		}
+		h.BookTopics[id] = topics
+		if owner.Valid {
+			h.BookOwners[id] = owner.String
+		}
+	}
+	return rows.Err()
+}
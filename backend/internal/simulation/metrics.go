@@ -0,0 +1,92 @@
+package simulation
+
+import "sort"
+
+type Metrics struct {
+	Allocations     int     `json:"allocations"`
+	Contested       int     `json:"contested"`
+	DistinctWinners int     `json:"distinct_winners"`
+	Gini            float64 `json:"gini"`
+	MedianWaitDays  float64 `json:"median_wait_days"`
+	AvgDistanceKm   float64 `json:"avg_distance_km"`
+	LowScoreShare   float64 `json:"low_score_share"`
+}
+
+// Measure summarises outcomes. Gini is taken over allocations per requester,
+// counting requesters who never received a book; LowScoreShare is the share
+// of allocations going to members whose score was below lowScore at the time.
+func Measure(outcomes []Outcome, requesters []string, lowScore int) Metrics {
+	m := Metrics{Allocations: len(outcomes)}
+
+	counts := make(map[string]int, len(requesters))
+	for _, id := range requesters {
+		counts[id] = 0
+	}
+
+	var waits []float64
+	var distanceSum float64
+	var distanceN, lowN int
+	for _, o := range outcomes {
+		counts[o.UserID]++
+		if o.Contested {
+			m.Contested++
+		}
+		waits = append(waits, o.WaitDays)
+		if o.DistanceKm != nil {
+			distanceSum += *o.DistanceKm
+			distanceN++
+		}
+		if o.ScoreAtTime < lowScore {
+			lowN++
+		}
+	}
+
+	values := make([]float64, 0, len(counts))
+	for _, n := range counts {
+		if n > 0 {
+			m.DistinctWinners++
+		}
+		values = append(values, float64(n))
+	}
+	m.Gini = gini(values)
+	m.MedianWaitDays = median(waits)
+	if distanceN > 0 {
+		m.AvgDistanceKm = distanceSum / float64(distanceN)
+	}
+	if len(outcomes) > 0 {
+		m.LowScoreShare = float64(lowN) / float64(len(outcomes))
+	}
+	return m
+}
+
+func gini(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum, weighted float64
+	for i, v := range sorted {
+		sum += v
+		weighted += float64(i+1) * v
+	}
+	if sum == 0 {
+		return 0
+	}
+	return 2*weighted/(float64(n)*sum) - float64(n+1)/float64(n)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,182 @@
+package simulation
+
+import (
+	"time"
+
+	"github.com/yourusername/online-library/internal/geocoding"
+	"github.com/yourusername/online-library/internal/models"
+	"github.com/yourusername/online-library/internal/services"
+)
+
+// Outcome is one book hand-over, either as it happened or as a policy would
+// have decided it.
+type Outcome struct {
+	BookID      string
+	UserID      string
+	RequestID   string
+	At          time.Time
+	WaitDays    float64
+	DistanceKm  *float64
+	ScoreAtTime int
+	Contested   bool
+}
+
+// Replay re-runs every historical hand-over that had at least one open
+// request, letting the policy pick the recipient. Scoring and ranking go
+// through the same services functions the live MatchingService uses, with
+// each requester's tier bonus taken from rules as of the hand-over.
+func Replay(h *History, policy *models.MatchingPolicy, rules *models.ScoreRuleSet) []Outcome {
+	allocated := make(map[string]bool)
+	holders := make(map[string]string)
+	for bookID, owner := range h.BookOwners {
+		holders[bookID] = owner
+	}
+	wins := make(map[string][]time.Time)
+
+	var outcomes []Outcome
+	for _, a := range h.Allocations {
+		open := h.openRequests(a.BookID, a.At, allocated)
+		if len(open) == 0 {
+			holders[a.BookID] = a.ReaderID
+			continue
+		}
+
+		candidates := make([]services.Candidate, 0, len(open))
+		breakdowns := make(map[string]models.ScoreBreakdown, len(open))
+		for _, r := range open {
+			user := h.Users[r.UserID]
+			if user == nil {
+				continue
+			}
+			score := user.ScoreAt(a.At)
+			in := services.MatchInputs{
+				SuccessScore:  score,
+				InterestMatch: services.InterestMatch(user.Interests, h.BookTopics[r.BookID]),
+				DistanceKm:    h.distance(holders[a.BookID], r.UserID),
+				WaitDays:      a.At.Sub(r.RequestedAt).Hours() / 24,
+				TierBonus:     rules.TierFor(score, user.CompletedLoansAt(a.At)).PriorityBonus,
+			}
+			b := services.ScoreRequest(policy, in)
+			breakdowns[r.ID] = b
+			candidates = append(candidates, services.Candidate{
+				RequestID:     r.ID,
+				UserID:        r.UserID,
+				PriorityScore: b.PriorityScore,
+				RequestedAt:   r.RequestedAt,
+				SuccessScore:  in.SuccessScore,
+				RecentWins:    countSince(wins[r.UserID], a.At.AddDate(0, 0, -policy.WinPeriodDays)),
+			})
+		}
+		if len(candidates) == 0 {
+			holders[a.BookID] = a.ReaderID
+			continue
+		}
+
+		winner := services.RankCandidates(policy, candidates)[0]
+		contested := len(candidates) > 1
+		outcomes = append(outcomes, Outcome{
+			BookID:      a.BookID,
+			UserID:      winner.UserID,
+			RequestID:   winner.RequestID,
+			At:          a.At,
+			WaitDays:    a.At.Sub(winner.RequestedAt).Hours() / 24,
+			DistanceKm:  breakdowns[winner.RequestID].DistanceKm,
+			ScoreAtTime: winner.SuccessScore,
+			Contested:   contested,
+		})
+
+		allocated[winner.RequestID] = true
+		holders[a.BookID] = winner.UserID
+		if contested {
+			wins[winner.UserID] = append(wins[winner.UserID], a.At)
+		}
+	}
+	return outcomes
+}
+
+// Actual reports the hand-overs as they really happened, for the same
+// events Replay considers, so the two can be compared side by side.
+func Actual(h *History) []Outcome {
+	allocated := make(map[string]bool)
+	holders := make(map[string]string)
+	for bookID, owner := range h.BookOwners {
+		holders[bookID] = owner
+	}
+
+	var outcomes []Outcome
+	for _, a := range h.Allocations {
+		open := h.openRequests(a.BookID, a.At, allocated)
+		var match *Request
+		for i := range open {
+			if open[i].UserID == a.ReaderID {
+				match = &open[i]
+			}
+		}
+		if match != nil {
+			o := Outcome{
+				BookID:     a.BookID,
+				UserID:     a.ReaderID,
+				RequestID:  match.ID,
+				At:         a.At,
+				WaitDays:   a.At.Sub(match.RequestedAt).Hours() / 24,
+				DistanceKm: h.distance(holders[a.BookID], a.ReaderID),
+				Contested:  len(open) > 1,
+			}
+			if user := h.Users[a.ReaderID]; user != nil {
+				o.ScoreAtTime = user.ScoreAt(a.At)
+			}
+			outcomes = append(outcomes, o)
+			allocated[match.ID] = true
+		}
+		holders[a.BookID] = a.ReaderID
+	}
+	return outcomes
+}
+
+// openRequests returns requests for a book that were waiting at time t:
+// made before t, not yet allocated in this run, and not withdrawn before t.
+func (h *History) openRequests(bookID string, t time.Time, allocated map[string]bool) []Request {
+	var open []Request
+	for _, r := range h.Requests {
+		if r.BookID != bookID || r.RequestedAt.After(t) || allocated[r.ID] {
+			continue
+		}
+		if r.Status == "cancelled" && r.ProcessedAt.Valid && !r.ProcessedAt.Time.After(t) {
+			continue
+		}
+		open = append(open, r)
+	}
+	return open
+}
+
+func (h *History) distance(fromUserID, toUserID string) *float64 {
+	from, to := h.Users[fromUserID], h.Users[toUserID]
+	if from == nil || to == nil || !from.Lat.Valid || !from.Lng.Valid || !to.Lat.Valid || !to.Lng.Valid {
+		return nil
+	}
+	d := geocoding.DistanceKm(from.Lat.Float64, from.Lng.Float64, to.Lat.Float64, to.Lng.Float64)
+	return &d
+}
+
+func countSince(times []time.Time, since time.Time) int {
+	n := 0
+	for _, t := range times {
+		if !t.Before(since) {
+			n++
+		}
+	}
+	return n
+}
+
+// Requesters returns everyone who made a request in the loaded window.
+func (h *History) Requesters() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, r := range h.Requests {
+		if !seen[r.UserID] {
+			seen[r.UserID] = true
+			ids = append(ids, r.UserID)
+		}
+	}
+	return ids
+}
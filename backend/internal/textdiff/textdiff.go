@@ -0,0 +1,112 @@
+// Package textdiff produces line-based diffs between two versions of a text.
+package textdiff
+
+import "strings"
+
+// Op marks how a line changed between two versions.
+type Op string
+
+const (
+	Equal  Op = " "
+	Insert Op = "+"
+	Delete Op = "-"
+)
+
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines diffs a and b line by line using their longest common subsequence.
+func Lines(a, b string) []Line {
+	x, y := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []Line
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, Line{Equal, x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, Line{Delete, x[i]})
+			i++
+		default:
+			out = append(out, Line{Insert, y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		out = append(out, Line{Delete, x[i]})
+	}
+	for ; j < len(y); j++ {
+		out = append(out, Line{Insert, y[j]})
+	}
+	return out
+}
+
+// Unified renders the diff of a and b with "+", "-" and " " line prefixes.
+// Runs of unchanged lines longer than 2*context are collapsed to "...".
+func Unified(a, b string, context int) string {
+	lines := Lines(a, b)
+
+	keep := make([]bool, len(lines))
+	changed := false
+	for i, l := range lines {
+		if l.Op == Equal {
+			continue
+		}
+		changed = true
+		for k := i - context; k <= i+context; k++ {
+			if k >= 0 && k < len(lines) {
+				keep[k] = true
+			}
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	skipped := false
+	for i, l := range lines {
+		if !keep[i] {
+			if !skipped {
+				sb.WriteString("...\n")
+				skipped = true
+			}
+			continue
+		}
+		skipped = false
+		sb.WriteString(string(l.Op))
+		sb.WriteString(l.Text)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
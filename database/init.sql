@@ -1,5 +1,7 @@
 -- Initial database setup
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
 
 -- Users table with success score and location
 CREATE TABLE IF NOT EXISTS users (
@@ -8,12 +10,15 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR(255) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
     full_name VARCHAR(255),
-    role VARCHAR(20) DEFAULT 'member' CHECK (role IN ('admin', 'member')),
+    role VARCHAR(20) DEFAULT 'member' CHECK (role IN ('admin', 'moderator', 'member')),
     avatar_url TEXT,
     bio TEXT,
+    bio_hidden_at TIMESTAMP,
     location_lat DECIMAL(10, 8),
     location_lng DECIMAL(11, 8),
     location_address TEXT,
+    location_district VARCHAR(100),
+    location_privacy VARCHAR(20) DEFAULT 'district' CHECK (location_privacy IN ('exact', 'neighbourhood', 'district', 'hidden')),
     success_score INTEGER DEFAULT 100,
     books_shared INTEGER DEFAULT 0,
     books_received INTEGER DEFAULT 0,
@@ -22,6 +27,7 @@ CREATE TABLE IF NOT EXISTS users (
     total_upvotes INTEGER DEFAULT 0,
     total_downvotes INTEGER DEFAULT 0,
     is_donor BOOLEAN DEFAULT FALSE,
+    warnings_received INTEGER DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -61,6 +67,7 @@ CREATE TABLE IF NOT EXISTS reading_history (
     notes TEXT,
     rating INTEGER CHECK (rating >= 1 AND rating <= 5),
     review TEXT,
+    interest_signal_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -74,10 +81,54 @@ CREATE TABLE IF NOT EXISTS book_requests (
     priority_score DECIMAL(10, 2) DEFAULT 0,
     interest_match_score DECIMAL(5, 2) DEFAULT 0,
     distance_km DECIMAL(10, 2),
+    success_component DECIMAL(10, 2),
+    interest_component DECIMAL(10, 2),
+    distance_component DECIMAL(10, 2),
+    wait_component DECIMAL(10, 2),
+    tier_component DECIMAL(10, 2),
+    policy_version INTEGER,
+    scored_at TIMESTAMP,
     requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     processed_at TIMESTAMP,
     due_date TIMESTAMP,
-    UNIQUE(book_id, user_id, status)
+    renewals INTEGER NOT NULL DEFAULT 0,
+    returned_at TIMESTAMP
+);
+
+-- Matching policies (versioned; exactly one active)
+CREATE TABLE IF NOT EXISTS matching_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    version SERIAL UNIQUE,
+    success_weight DECIMAL(5, 3) NOT NULL,
+    interest_weight DECIMAL(5, 3) NOT NULL,
+    distance_weight DECIMAL(5, 3) NOT NULL,
+    wait_weight DECIMAL(5, 3) NOT NULL DEFAULT 0,
+    distance_decay VARCHAR(20) NOT NULL DEFAULT 'linear' CHECK (distance_decay IN ('linear', 'exponential')),
+    distance_scale_km DECIMAL(10, 2) NOT NULL DEFAULT 1000,
+    missing_distance_km DECIMAL(10, 2) NOT NULL DEFAULT 10000,
+    wait_saturation_days INTEGER NOT NULL DEFAULT 30,
+    aging_points_per_day DECIMAL(6, 3) NOT NULL DEFAULT 0,
+    max_wins_per_period INTEGER NOT NULL DEFAULT 0,
+    win_period_days INTEGER NOT NULL DEFAULT 30,
+    tie_break VARCHAR(30) NOT NULL DEFAULT 'oldest_request' CHECK (tie_break IN ('oldest_request', 'fewest_recent_wins', 'lowest_success_score')),
+    notes TEXT,
+    is_active BOOLEAN DEFAULT FALSE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Precomputed book-to-book similarity, refreshed by a background job
+CREATE TABLE IF NOT EXISTS book_similarities (
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    similar_book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    score DECIMAL(6, 4) NOT NULL,
+    topic_score DECIMAL(6, 4) NOT NULL DEFAULT 0,
+    tag_score DECIMAL(6, 4) NOT NULL DEFAULT 0,
+    same_author BOOLEAN NOT NULL DEFAULT FALSE,
+    same_category BOOLEAN NOT NULL DEFAULT FALSE,
+    coreader_score DECIMAL(6, 4) NOT NULL DEFAULT 0,
+    computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (book_id, similar_book_id)
 );
 
 -- Waiting queue table (legacy support)
@@ -98,10 +149,34 @@ CREATE TABLE IF NOT EXISTS reading_ideas (
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     title VARCHAR(500) NOT NULL,
     content TEXT NOT NULL,
+    chapter VARCHAR(200),
+    page_start INTEGER CHECK (page_start >= 1),
+    page_end INTEGER,
+    quote TEXT,
     upvotes INTEGER DEFAULT 0,
     downvotes INTEGER DEFAULT 0,
+    comment_count INTEGER DEFAULT 0,
+    edited_at TIMESTAMP,
+    hidden_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    deleted_by UUID REFERENCES users(id) ON DELETE SET NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (page_end IS NULL OR (page_start IS NOT NULL AND page_end >= page_start))
+);
+
+-- Every version of an idea; revision 1 is the original post
+CREATE TABLE IF NOT EXISTS idea_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    idea_id UUID NOT NULL REFERENCES reading_ideas(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(500) NOT NULL,
+    content TEXT NOT NULL,
+    title_diff TEXT,
+    content_diff TEXT,
+    edited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(idea_id, revision)
 );
 
 -- Idea votes
@@ -114,6 +189,33 @@ CREATE TABLE IF NOT EXISTS idea_votes (
     UNIQUE(idea_id, user_id)
 );
 
+-- Threaded discussion on reading ideas
+CREATE TABLE IF NOT EXISTS idea_comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    idea_id UUID NOT NULL REFERENCES reading_ideas(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES idea_comments(id) ON DELETE CASCADE,
+    depth INTEGER NOT NULL DEFAULT 0,
+    content TEXT NOT NULL,
+    upvotes INTEGER DEFAULT 0,
+    downvotes INTEGER DEFAULT 0,
+    edited_at TIMESTAMP,
+    hidden_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Comment votes
+CREATE TABLE IF NOT EXISTS comment_votes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    comment_id UUID NOT NULL REFERENCES idea_comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    vote_type VARCHAR(10) CHECK (vote_type IN ('upvote', 'downvote')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(comment_id, user_id)
+);
+
 -- User reviews (user-to-user after book exchange)
 CREATE TABLE IF NOT EXISTS user_reviews (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -124,7 +226,27 @@ CREATE TABLE IF NOT EXISTS user_reviews (
     book_condition_rating INTEGER CHECK (book_condition_rating >= 1 AND book_condition_rating <= 5),
     communication_rating INTEGER CHECK (communication_rating >= 1 AND communication_rating <= 5),
     comment TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    -- The receiver's reading_history entry for the handover being reviewed
+    exchange_id UUID REFERENCES reading_history(id) ON DELETE SET NULL,
+    hidden_at TIMESTAMP,
+    removed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (reviewer_id <> reviewee_id),
+    UNIQUE(exchange_id, reviewer_id)
+);
+
+-- Disputes raised by reviewees against reviews they consider unfair
+CREATE TABLE IF NOT EXISTS review_disputes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    review_id UUID NOT NULL UNIQUE REFERENCES user_reviews(id) ON DELETE CASCADE,
+    disputed_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    statement TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'open' CHECK (status IN ('open', 'upheld', 'removed')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolution_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
 );
 
 -- Book donations
@@ -137,6 +259,43 @@ CREATE TABLE IF NOT EXISTS donations (
     currency VARCHAR(10) DEFAULT 'USD',
     message TEXT,
     is_public BOOLEAN DEFAULT TRUE,
+    hidden_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Member reports of abusive content. resource_type/resource_id point at an
+-- idea, comment, review, a user's bio or a donation message; author_id is
+-- whoever wrote it, captured when the report is filed. reporter_id is NULL
+-- for posts flagged by the automatic content filter. auto_hid marks the open
+-- reports that hid the content by reaching the report threshold.
+CREATE TABLE IF NOT EXISTS content_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    resource_type VARCHAR(20) NOT NULL CHECK (resource_type IN ('idea', 'comment', 'review', 'bio', 'donation')),
+    resource_id UUID NOT NULL,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reporter_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'sexual', 'misinformation', 'other', 'filter')),
+    details TEXT,
+    status VARCHAR(20) DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+    action VARCHAR(20) CHECK (action IN ('hide', 'delete', 'warn')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolution_note TEXT,
+    auto_hid BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    UNIQUE(resource_type, resource_id, reporter_id)
+);
+
+-- Posts that passed the content filter, for its duplicate and rate limit
+-- checks. fingerprint is NULL for posts too short to compare.
+CREATE TABLE IF NOT EXISTS content_submissions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    resource_id UUID,
+    fingerprint CHAR(64),
+    verdict VARCHAR(10) NOT NULL CHECK (verdict IN ('allow', 'flag')),
+    reasons TEXT[],
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -145,11 +304,44 @@ CREATE TABLE IF NOT EXISTS user_interests (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     interest VARCHAR(100) NOT NULL,
-    weight DECIMAL(5, 2) DEFAULT 1.0,
+    weight DECIMAL(6, 3) DEFAULT 1.0,
+    explicit_weight DECIMAL(6, 3), -- set by the member; learned weight decays back towards it
+    last_signal_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(user_id, interest)
 );
 
+-- When learned interest weights were last decayed. One row shared by every
+-- API instance, so decay follows real elapsed time across restarts
+CREATE TABLE IF NOT EXISTS interest_decay_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_decayed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Controlled vocabulary for book categories, tags and topics
+CREATE TABLE IF NOT EXISTS taxonomy_terms (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('category', 'tag', 'topic')),
+    name VARCHAR(100) NOT NULL,
+    normalized VARCHAR(100) NOT NULL,
+    parent_id UUID REFERENCES taxonomy_terms(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(kind, normalized)
+);
+
+-- Synonyms, spelling variants and translations that map to a canonical term
+CREATE TABLE IF NOT EXISTS taxonomy_aliases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    term_id UUID NOT NULL REFERENCES taxonomy_terms(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    alias VARCHAR(100) NOT NULL,
+    normalized VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(kind, normalized)
+);
+
 -- Bookmarks/Likes
 CREATE TABLE IF NOT EXISTS user_bookmarks (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -174,16 +366,82 @@ CREATE TABLE IF NOT EXISTS notifications (
 );
 
 -- Success score history
+-- Success score rules: what each scoring event is worth, how much it may
+-- add per period, and the score thresholds. Rule sets are append-only like
+-- matching policies; each change creates a new version.
+CREATE TABLE IF NOT EXISTS score_rule_sets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    version SERIAL UNIQUE,
+    starting_score INTEGER NOT NULL DEFAULT 100,
+    min_request_score INTEGER NOT NULL DEFAULT 20,
+    notes TEXT,
+    is_active BOOLEAN DEFAULT FALSE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS score_rules (
+    rule_set_id UUID NOT NULL REFERENCES score_rule_sets(id) ON DELETE CASCADE,
+    event_type VARCHAR(40) NOT NULL,
+    delta INTEGER NOT NULL,
+    period_cap INTEGER NOT NULL DEFAULT 0 CHECK (period_cap >= 0), -- 0 = no cap
+    cap_period_days INTEGER NOT NULL DEFAULT 0 CHECK (cap_period_days >= 0),
+    PRIMARY KEY (rule_set_id, event_type)
+);
+
+-- Membership tiers of a rule set. A member is in the highest tier whose
+-- score and completed-loan thresholds they meet, or the lowest tier.
+CREATE TABLE IF NOT EXISTS score_tiers (
+    rule_set_id UUID NOT NULL REFERENCES score_rule_sets(id) ON DELETE CASCADE,
+    level INTEGER NOT NULL CHECK (level >= 1),
+    name VARCHAR(50) NOT NULL,
+    min_score INTEGER NOT NULL DEFAULT 0,
+    min_completed_loans INTEGER NOT NULL DEFAULT 0 CHECK (min_completed_loans >= 0),
+    max_active_loans INTEGER NOT NULL CHECK (max_active_loans >= 1),
+    max_loan_days INTEGER NOT NULL CHECK (max_loan_days >= 1),
+    max_renewals INTEGER NOT NULL DEFAULT 0 CHECK (max_renewals >= 0),
+    priority_bonus DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (rule_set_id, level)
+);
+
+-- Every score change as the event that caused it, so scores can be rebuilt
+-- under new rules. Negative units reverse earlier events on the same
+-- reference; rows without an event type are fixed adjustments.
 CREATE TABLE IF NOT EXISTS success_score_history (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     change_amount INTEGER NOT NULL,
     reason VARCHAR(255) NOT NULL,
+    event_type VARCHAR(40),
+    units INTEGER NOT NULL DEFAULT 1,
+    rule_set_version INTEGER,
     reference_type VARCHAR(50),
     reference_id UUID,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Badge definitions. A badge is earned once a member's running total for
+-- its metric reaches the threshold.
+CREATE TABLE IF NOT EXISTS badges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    icon VARCHAR(50),
+    metric VARCHAR(40) NOT NULL CHECK (metric IN ('books_shared', 'on_time_returns', 'upvotes_received', 'donations', 'km_travelled')),
+    threshold INTEGER NOT NULL CHECK (threshold >= 1),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Badges members have earned; each is awarded at most once
+CREATE TABLE IF NOT EXISTS user_badges (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    badge_id UUID NOT NULL REFERENCES badges(id) ON DELETE CASCADE,
+    awarded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, badge_id)
+);
+
 -- Audit logs table
 CREATE TABLE IF NOT EXISTS audit_logs (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -203,26 +461,48 @@ CREATE INDEX idx_books_current_holder ON books(current_holder_id);
 CREATE INDEX idx_books_donated ON books(donated_by);
 CREATE INDEX idx_reading_history_book ON reading_history(book_id);
 CREATE INDEX idx_reading_history_reader ON reading_history(reader_id);
+CREATE INDEX idx_reading_history_book_start ON reading_history(book_id, start_date);
+CREATE INDEX idx_book_similarities_score ON book_similarities(book_id, score DESC);
 CREATE INDEX idx_waiting_queue_book ON waiting_queue(book_id);
 CREATE INDEX idx_waiting_queue_user ON waiting_queue(user_id);
 CREATE INDEX idx_book_requests_book ON book_requests(book_id);
 CREATE INDEX idx_book_requests_user ON book_requests(user_id);
 CREATE INDEX idx_book_requests_status ON book_requests(status);
+CREATE UNIQUE INDEX idx_book_requests_pending ON book_requests(book_id, user_id) WHERE status = 'pending';
+CREATE INDEX idx_book_requests_active_loans ON book_requests(user_id) WHERE status = 'approved' AND returned_at IS NULL;
+CREATE UNIQUE INDEX idx_matching_policies_active ON matching_policies(is_active) WHERE is_active;
+CREATE UNIQUE INDEX idx_score_rule_sets_active ON score_rule_sets(is_active) WHERE is_active;
 CREATE INDEX idx_reading_ideas_book ON reading_ideas(book_id);
 CREATE INDEX idx_reading_ideas_user ON reading_ideas(user_id);
+CREATE INDEX idx_reading_ideas_created ON reading_ideas(created_at DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_reading_ideas_chapter ON reading_ideas(book_id, LOWER(chapter)) WHERE deleted_at IS NULL;
+CREATE INDEX idx_idea_comments_idea ON idea_comments(idea_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX idx_idea_comments_parent ON idea_comments(parent_id);
 CREATE INDEX idx_user_reviews_reviewee ON user_reviews(reviewee_id);
 CREATE INDEX idx_user_reviews_reviewer ON user_reviews(reviewer_id);
+CREATE INDEX idx_review_disputes_status ON review_disputes(status, created_at);
 CREATE INDEX idx_donations_donor ON donations(donor_id);
+CREATE INDEX idx_content_reports_resource ON content_reports(resource_type, resource_id) WHERE status = 'open';
+CREATE INDEX idx_content_reports_status ON content_reports(status, created_at);
+CREATE INDEX idx_content_submissions_user ON content_submissions(user_id, kind, created_at);
+CREATE INDEX idx_content_submissions_fingerprint ON content_submissions(fingerprint, created_at) WHERE fingerprint IS NOT NULL;
 CREATE INDEX idx_user_interests_user ON user_interests(user_id);
+CREATE INDEX idx_taxonomy_terms_parent ON taxonomy_terms(parent_id);
+CREATE INDEX idx_taxonomy_aliases_term ON taxonomy_aliases(term_id);
 CREATE INDEX idx_user_bookmarks_user ON user_bookmarks(user_id);
 CREATE INDEX idx_user_bookmarks_book ON user_bookmarks(book_id);
 CREATE INDEX idx_notifications_user ON notifications(user_id);
 CREATE INDEX idx_notifications_read ON notifications(is_read);
 CREATE INDEX idx_success_score_history_user ON success_score_history(user_id);
+CREATE INDEX idx_success_score_history_event ON success_score_history(user_id, event_type, created_at);
+CREATE INDEX idx_badges_metric ON badges(metric) WHERE is_active;
+CREATE INDEX idx_user_badges_user ON user_badges(user_id, awarded_at);
 CREATE INDEX idx_audit_logs_user ON audit_logs(user_id);
 CREATE INDEX idx_audit_logs_created ON audit_logs(created_at);
 CREATE INDEX idx_users_success_score ON users(success_score);
 CREATE INDEX idx_users_location ON users(location_lat, location_lng);
+CREATE INDEX idx_users_location_earth ON users USING gist (ll_to_earth(location_lat::float8, location_lng::float8))
+    WHERE location_lat IS NOT NULL AND location_lng IS NOT NULL;
 
 -- Function to update updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -242,3 +522,64 @@ CREATE TRIGGER update_books_updated_at BEFORE UPDATE ON books
 
 CREATE TRIGGER update_reading_history_updated_at BEFORE UPDATE ON reading_history
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_taxonomy_terms_updated_at BEFORE UPDATE ON taxonomy_terms
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_idea_comments_updated_at BEFORE UPDATE ON idea_comments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_user_reviews_updated_at BEFORE UPDATE ON user_reviews
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_user_interests_updated_at BEFORE UPDATE ON user_interests
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Default matching policy: the original 0.4/0.3/0.3 formula plus aging and a
+-- fairness cap so high-score members cannot win every contested book
+INSERT INTO matching_policies (success_weight, interest_weight, distance_weight, wait_weight,
+    distance_decay, distance_scale_km, missing_distance_km, aging_points_per_day,
+    max_wins_per_period, win_period_days, tie_break, notes, is_active)
+VALUES (0.4, 0.3, 0.3, 0, 'linear', 1000, 10000, 0.5, 2, 30, 'fewest_recent_wins', 'Initial policy', TRUE);
+
+-- Default score rules: the original fixed deltas, uncapped
+WITH rule_set AS (
+    INSERT INTO score_rule_sets (starting_score, min_request_score, notes, is_active)
+    VALUES (100, 20, 'Initial rules', TRUE)
+    RETURNING id
+)
+INSERT INTO score_rules (rule_set_id, event_type, delta, period_cap, cap_period_days)
+SELECT rule_set.id, r.event_type, r.delta, r.period_cap, r.cap_period_days
+FROM rule_set, (VALUES
+    ('return_on_time', 10, 0, 0),
+    ('return_late', -15, 0, 0),
+    ('positive_review', 5, 0, 0),
+    ('negative_review', -10, 0, 0),
+    ('idea_posted', 3, 0, 0),
+    ('idea_upvote', 1, 0, 0),
+    ('idea_downvote', -1, 0, 0),
+    ('lost_book', -50, 0, 0),
+    ('book_donated', 20, 0, 0),
+    ('money_donated', 10, 0, 0)
+) AS r(event_type, delta, period_cap, cap_period_days);
+
+-- Default tiers: newcomers borrow one book at a time, and members with a
+-- record of returned books earn more and longer loans and a priority boost
+INSERT INTO score_tiers (rule_set_id, level, name, min_score, min_completed_loans,
+    max_active_loans, max_loan_days, max_renewals, priority_bonus)
+SELECT s.id, t.level, t.name, t.min_score, t.min_completed_loans,
+    t.max_active_loans, t.max_loan_days, t.max_renewals, t.priority_bonus
+FROM score_rule_sets s, (VALUES
+    (1, 'New', 0, 0, 1, 14, 0, 0),
+    (2, 'Trusted', 150, 3, 3, 21, 1, 5),
+    (3, 'Champion', 300, 10, 5, 30, 2, 10)
+) AS t(level, name, min_score, min_completed_loans, max_active_loans, max_loan_days, max_renewals, priority_bonus)
+WHERE s.is_active;
+
+-- Default badges
+INSERT INTO badges (code, name, description, icon, metric, threshold) VALUES
+    ('first_share', 'First Share', 'Passed a book on to another reader', 'gift', 'books_shared', 1),
+    ('punctual_reader', 'Punctual Reader', 'Returned 10 books on time', 'clock', 'on_time_returns', 10),
+    ('crowd_favourite', 'Crowd Favourite', 'Received 100 upvotes on reading ideas', 'heart', 'upvotes_received', 100),
+    ('first_donation', 'First Donation', 'Made a first donation to the library', 'hand-heart', 'donations', 1),
+    ('globetrotter', 'Globetrotter', 'Listed books that travelled 500 km between readers', 'map', 'km_travelled', 500);